@@ -0,0 +1,80 @@
+package evtc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+const eliteNPC = 0xffffffff
+
+// Agent is an entry of the agent table: a player, an NPC or a gadget.
+type Agent struct {
+	Address uint64
+	// Profession is the profession of players, the species id of NPCs and the volatile id of gadgets.
+	Profession uint32
+	// IsElite is the elite specialization of players. It is 0xffffffff for NPCs and gadgets.
+	IsElite       uint32
+	Toughness     int16
+	Concentration int16
+	Healing       int16
+	HitboxWidth   int16
+	Condition     int16
+	HitboxHeight  int16
+	Name          string
+
+	// CharacterName, AccountName and Subgroup are only set for players.
+	CharacterName string
+	AccountName   string
+	Subgroup      int
+}
+
+func decodeAgent(buf []byte) Agent {
+	agent := Agent{
+		Address:       binary.LittleEndian.Uint64(buf[0:8]),
+		Profession:    binary.LittleEndian.Uint32(buf[8:12]),
+		IsElite:       binary.LittleEndian.Uint32(buf[12:16]),
+		Toughness:     int16(binary.LittleEndian.Uint16(buf[16:18])),
+		Concentration: int16(binary.LittleEndian.Uint16(buf[18:20])),
+		Healing:       int16(binary.LittleEndian.Uint16(buf[20:22])),
+		HitboxWidth:   int16(binary.LittleEndian.Uint16(buf[22:24])),
+		Condition:     int16(binary.LittleEndian.Uint16(buf[24:26])),
+		HitboxHeight:  int16(binary.LittleEndian.Uint16(buf[26:28])),
+	}
+
+	name := buf[28 : 28+agentNameSize]
+	agent.Name = cString(name)
+	if agent.IsPlayer() {
+		// players carry "character\0:account.1234\0subgroup\0" in their name
+		parts := bytes.SplitN(name, []byte{0}, 4)
+		agent.CharacterName = string(parts[0])
+		if len(parts) > 1 {
+			agent.AccountName = strings.TrimPrefix(string(parts[1]), ":")
+		}
+		if len(parts) > 2 {
+			agent.Subgroup, _ = strconv.Atoi(strings.TrimSpace(string(parts[2])))
+		}
+	}
+	return agent
+}
+
+// IsPlayer reports whether the agent is a player character.
+func (a *Agent) IsPlayer() bool {
+	return a.IsElite != eliteNPC
+}
+
+// IsGadget reports whether the agent is a gadget, e.g. a siege weapon or an object of an encounter.
+func (a *Agent) IsGadget() bool {
+	return a.IsElite == eliteNPC && a.Profession>>16 == 0xffff
+}
+
+// IsNPC reports whether the agent is a non player character.
+func (a *Agent) IsNPC() bool {
+	return a.IsElite == eliteNPC && a.Profession>>16 != 0xffff
+}
+
+// SpeciesID returns the species id of NPCs.
+func (a *Agent) SpeciesID() uint16 {
+	return uint16(a.Profession & 0xffff)
+}
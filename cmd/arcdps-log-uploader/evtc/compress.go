@@ -0,0 +1,107 @@
+package evtc
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IsCompressed reports whether the log is already zipped, as arcdps does for .zevtc and .evtc.zip logs.
+func IsCompressed(r io.ReaderAt) (bool, error) {
+	start := make([]byte, len(zipMagic))
+	if _, err := r.ReadAt(start, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(start, zipMagic), nil
+}
+
+// CompressedName returns the file name of the raw log after compression, e.g. "20240518-201500.zevtc".
+func CompressedName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".zevtc"
+}
+
+// Compress writes the raw log read from r to w as a zip archive with a single entry, the format of .zevtc logs.
+func Compress(w io.Writer, r io.Reader, name string, modified time.Time) error {
+	archive := zip.NewWriter(w)
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, r); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// CompressFile writes a compressed copy of a raw log file next to it and returns its path.
+// Logs that are already compressed are left untouched and their own path is returned.
+func CompressFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	compressed, err := IsCompressed(file)
+	if err != nil || compressed {
+		return path, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// an existing compressed log is never replaced, as the raw log may be deleted afterwards
+	target := filepath.Join(filepath.Dir(path), CompressedName(filepath.Base(path)))
+	if err := checkNotExists(target); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := Compress(tmp, file, filepath.Base(path), stat.ModTime()); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chtimes(tmp.Name(), stat.ModTime(), stat.ModTime()); err != nil {
+		return "", err
+	}
+	if err := checkNotExists(target); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+func checkNotExists(path string) error {
+	_, err := os.Lstat(path)
+	if err == nil {
+		return fmt.Errorf("%v: %w", filepath.Base(path), fs.ErrExist)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package evtc
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressFile(t *testing.T) {
+	raw := writeFile(t, "20240518-201500.evtc", defaultFixture(1).bytes())
+
+	compressed, err := CompressFile(raw)
+	if err != nil {
+		t.Fatalf("CompressFile() error = %v", err)
+	}
+	if want := filepath.Join(filepath.Dir(raw), "20240518-201500.zevtc"); compressed != want {
+		t.Errorf("CompressFile() = %v, want %v", compressed, want)
+	}
+	log, err := ReadFile(compressed)
+	if err != nil {
+		t.Fatalf("ReadFile() of the compressed log error = %v", err)
+	}
+	if log.SpeciesID != testSpecies {
+		t.Errorf("SpeciesID = %v, want %v", log.SpeciesID, testSpecies)
+	}
+
+	// compressed logs are left as they are
+	if again, err := CompressFile(compressed); err != nil || again != compressed {
+		t.Errorf("CompressFile() of a compressed log = %v, %v", again, err)
+	}
+}
+
+func TestCompressFileExistingTarget(t *testing.T) {
+	raw := writeFile(t, "20240518-201500.evtc", defaultFixture(1).bytes())
+	target := filepath.Join(filepath.Dir(raw), "20240518-201500.zevtc")
+	existing := []byte("another log")
+	if err := os.WriteFile(target, existing, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CompressFile(raw); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("CompressFile() error = %v, want %v", err, fs.ErrExist)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil || !bytes.Equal(content, existing) {
+		t.Errorf("existing log was replaced: %q, %v", content, err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(raw))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("directory contains %v entries, want only the raw and the existing log", len(entries))
+	}
+}
@@ -0,0 +1,136 @@
+package evtc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+)
+
+// Encounter is the outcome of the recorded fight, derived from the combat events.
+type Encounter struct {
+	// Start and End are the server times the log started and ended, if the log contains them.
+	Start time.Time
+	End   time.Time
+	// Duration is the combat time from the boss entering combat until it died or the log ended.
+	Duration time.Duration
+	BossDied bool
+	Rewarded bool
+	// BossHealth is the last known health of the boss in percent.
+	BossHealth      float64
+	BossHealthKnown bool
+}
+
+// Success reports whether the boss was killed. Bosses that do not die, but reward the squad, count as killed.
+func (e *Encounter) Success() bool {
+	return e.BossDied || e.Rewarded
+}
+
+// AnalyzeFile reads the whole log file and determines the outcome of the encounter.
+func AnalyzeFile(path string) (*Log, *Encounter, error) {
+	reader, err := Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	buffered := bufio.NewReaderSize(reader, 64*1024)
+	log, err := Read(buffered)
+	if err != nil {
+		return nil, nil, err
+	}
+	encounter, err := log.Analyze(buffered)
+	if err != nil {
+		return nil, nil, err
+	}
+	return log, encounter, nil
+}
+
+// Analyze walks the combat events following the skill table in r.
+func (l *Log) Analyze(r io.Reader) (*Encounter, error) {
+	bosses := make(map[uint64]struct{})
+	for i := range l.Agents {
+		agent := &l.Agents[i]
+		if agent.IsNPC() && agent.SpeciesID() == l.SpeciesID && l.SpeciesID != SpeciesWvW {
+			bosses[agent.Address] = struct{}{}
+		}
+	}
+	isBoss := func(address uint64) bool {
+		_, found := bosses[address]
+		return found
+	}
+
+	encounter := &Encounter{}
+	var firstTime, lastTime, logStartTime, logEndTime, combatStartTime, deathTime uint64
+
+	events := NewEventReader(r, l.Revision)
+	for {
+		event, err := events.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if event.Time != 0 {
+			if firstTime == 0 {
+				firstTime = event.Time
+			}
+			if event.Time > lastTime {
+				lastTime = event.Time
+			}
+		}
+
+		// only the state changes describing the course of the encounter are of interest
+		switch event.StateChange { //nolint:exhaustive
+		case StateChangeLogStart:
+			encounter.Start = time.Unix(int64(uint32(event.Value)), 0)
+			logStartTime = event.Time
+		case StateChangeLogEnd:
+			encounter.End = time.Unix(int64(uint32(event.Value)), 0)
+			logEndTime = event.Time
+		case StateChangeEnterCombat:
+			if isBoss(event.SrcAgent) && combatStartTime == 0 {
+				combatStartTime = event.Time
+			}
+		case StateChangeChangeDead:
+			if isBoss(event.SrcAgent) && !encounter.BossDied {
+				encounter.BossDied = true
+				deathTime = event.Time
+			}
+		case StateChangeHealthUpdate:
+			if isBoss(event.SrcAgent) {
+				// the health is sent as percent * 100 in dst_agent
+				encounter.BossHealth = float64(event.DstAgent) / 100
+				encounter.BossHealthKnown = true
+			}
+		case StateChangeReward:
+			encounter.Rewarded = true
+		}
+	}
+
+	start := firstNonZero(combatStartTime, logStartTime, firstTime)
+	end := lastTime
+	if deathTime != 0 {
+		end = deathTime
+	} else if logEndTime != 0 {
+		end = logEndTime
+	}
+	if end > start {
+		encounter.Duration = time.Duration(end-start) * time.Millisecond
+	}
+	if encounter.BossDied {
+		encounter.BossHealth = 0
+	}
+	return encounter, nil
+}
+
+func firstNonZero(values ...uint64) uint64 {
+	for _, value := range values {
+		if value != 0 {
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package evtc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+const (
+	testLogStart = 1700000000
+	testLogEnd   = 1700000070
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []Event
+		died        bool
+		rewarded    bool
+		health      float64
+		healthKnown bool
+		duration    time.Duration
+		start       time.Time
+		end         time.Time
+	}{
+		{
+			name: "kill",
+			events: []Event{
+				{Time: 1000, Value: testLogStart, StateChange: StateChangeLogStart},
+				{Time: 2000, SrcAgent: testBossAddr, StateChange: StateChangeEnterCombat},
+				{Time: 5000, SrcAgent: testBossAddr, DstAgent: 5000, StateChange: StateChangeHealthUpdate},
+				{Time: 62000, SrcAgent: testBossAddr, StateChange: StateChangeChangeDead},
+				{Time: 70000, Value: testLogEnd, StateChange: StateChangeLogEnd},
+			},
+			died:        true,
+			healthKnown: true,
+			duration:    60 * time.Second,
+			start:       time.Unix(testLogStart, 0),
+			end:         time.Unix(testLogEnd, 0),
+		},
+		{
+			name: "reward without death",
+			events: []Event{
+				{Time: 1000, Value: testLogStart, StateChange: StateChangeLogStart},
+				{Time: 2000, SrcAgent: testBossAddr, StateChange: StateChangeEnterCombat},
+				{Time: 40000, SrcAgent: testBossAddr, DstAgent: 150, StateChange: StateChangeHealthUpdate},
+				{Time: 50000, StateChange: StateChangeReward},
+				{Time: 52000, Value: testLogEnd, StateChange: StateChangeLogEnd},
+			},
+			rewarded:    true,
+			health:      1.5,
+			healthKnown: true,
+			duration:    50 * time.Second,
+			start:       time.Unix(testLogStart, 0),
+			end:         time.Unix(testLogEnd, 0),
+		},
+		{
+			name: "wipe measured from log start",
+			events: []Event{
+				{Time: 1000, Value: testLogStart, StateChange: StateChangeLogStart},
+				{Time: 20000, SrcAgent: testBossAddr, DstAgent: 7550, StateChange: StateChangeHealthUpdate},
+				{Time: 25000, SrcAgent: testPlayerAddr, StateChange: StateChangeChangeDead},
+				{Time: 31000, Value: testLogEnd, StateChange: StateChangeLogEnd},
+			},
+			health:      75.5,
+			healthKnown: true,
+			duration:    30 * time.Second,
+			start:       time.Unix(testLogStart, 0),
+			end:         time.Unix(testLogEnd, 0),
+		},
+		{
+			name: "measured from the first and last event",
+			events: []Event{
+				{Time: 500, SrcAgent: testPlayerAddr, DstAgent: testBossAddr, Value: 1234},
+				{Time: 3000, SrcAgent: testPlayerAddr, DstAgent: 9000, StateChange: StateChangeHealthUpdate},
+				{Time: 10500, SrcAgent: testPlayerAddr, DstAgent: testBossAddr, Value: 4321},
+			},
+			duration: 10 * time.Second,
+		},
+	}
+	for _, revision := range []byte{0, 1} {
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				f := defaultFixture(revision)
+				f.events = test.events
+				r := bytes.NewReader(f.bytes())
+				log, err := Read(r)
+				if err != nil {
+					t.Fatalf("revision %d: Read() error = %v", revision, err)
+				}
+				encounter, err := log.Analyze(r)
+				if err != nil {
+					t.Fatalf("revision %d: Analyze() error = %v", revision, err)
+				}
+
+				if encounter.BossDied != test.died || encounter.Rewarded != test.rewarded {
+					t.Errorf("revision %d: died = %v, rewarded = %v", revision, encounter.BossDied, encounter.Rewarded)
+				}
+				if encounter.Success() != (test.died || test.rewarded) {
+					t.Errorf("revision %d: Success() = %v", revision, encounter.Success())
+				}
+				if encounter.BossHealthKnown != test.healthKnown || encounter.BossHealth != test.health {
+					t.Errorf("revision %d: boss health = %v (known %v), want %v", revision,
+						encounter.BossHealth, encounter.BossHealthKnown, test.health)
+				}
+				if encounter.Duration != test.duration {
+					t.Errorf("revision %d: Duration = %v, want %v", revision, encounter.Duration, test.duration)
+				}
+				if !encounter.Start.Equal(test.start) || !encounter.End.Equal(test.end) {
+					t.Errorf("revision %d: Start = %v, End = %v", revision, encounter.Start, encounter.End)
+				}
+			})
+		}
+	}
+}
+
+func TestAnalyzeTruncated(t *testing.T) {
+	f := defaultFixture(1)
+	f.events = []Event{
+		{Time: 1000, SrcAgent: testBossAddr, StateChange: StateChangeEnterCombat},
+		{Time: 9000, SrcAgent: testBossAddr, DstAgent: 2500, StateChange: StateChangeHealthUpdate},
+		{Time: 12000, SrcAgent: testBossAddr, StateChange: StateChangeChangeDead},
+	}
+	raw := f.bytes()
+	// the game crashed while writing the death of the boss
+	r := bytes.NewReader(raw[:len(raw)-10])
+	log, err := Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encounter, err := log.Analyze(r)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if encounter.BossDied || encounter.BossHealth != 25 || encounter.Duration != 8*time.Second {
+		t.Errorf("got %+v", encounter)
+	}
+}
@@ -0,0 +1,143 @@
+package evtc
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const eventSize = 64
+
+type StateChange uint8
+
+const (
+	StateChangeNone StateChange = iota
+	StateChangeEnterCombat
+	StateChangeExitCombat
+	StateChangeChangeUp
+	StateChangeChangeDead
+	StateChangeChangeDown
+	StateChangeSpawn
+	StateChangeDespawn
+	StateChangeHealthUpdate
+	StateChangeLogStart
+	StateChangeLogEnd
+	StateChangeWeaponSwap
+	StateChangeMaxHealthUpdate
+	StateChangePointOfView
+	StateChangeLanguage
+	StateChangeGWBuild
+	StateChangeShardID
+	StateChangeReward
+)
+
+// Event is a combat event, decoded from either event struct revision.
+type Event struct {
+	Time            uint64
+	SrcAgent        uint64
+	DstAgent        uint64
+	Value           int32
+	BuffDmg         int32
+	OverstackValue  uint32
+	SkillID         uint32
+	SrcInstID       uint16
+	DstInstID       uint16
+	SrcMasterInstID uint16
+	DstMasterInstID uint16
+	IFF             uint8
+	Buff            uint8
+	Result          uint8
+	IsActivation    uint8
+	IsBuffRemove    uint8
+	IsNinety        uint8
+	IsFifty         uint8
+	IsMoving        uint8
+	StateChange     StateChange
+	IsFlanking      uint8
+	IsShields       uint8
+	IsOffcycle      uint8
+}
+
+// EventReader decodes the combat events following the skill table.
+type EventReader struct {
+	r        io.Reader
+	revision byte
+	buf      []byte
+}
+
+// NewEventReader returns a reader for events of the given header revision.
+func NewEventReader(r io.Reader, revision byte) *EventReader {
+	return &EventReader{r: r, revision: revision, buf: make([]byte, eventSize)}
+}
+
+// Next returns the next event, or io.EOF at the end of the log.
+// A truncated last event, as written when the game crashes, is treated as the end of the log.
+func (e *EventReader) Next() (*Event, error) {
+	if _, err := io.ReadFull(e.r, e.buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if e.revision == 0 {
+		return decodeEventRevision0(e.buf), nil
+	}
+	return decodeEventRevision1(e.buf), nil
+}
+
+func decodeEventRevision0(buf []byte) *Event {
+	le := binary.LittleEndian
+	return &Event{
+		Time:            le.Uint64(buf[0:8]),
+		SrcAgent:        le.Uint64(buf[8:16]),
+		DstAgent:        le.Uint64(buf[16:24]),
+		Value:           int32(le.Uint32(buf[24:28])),
+		BuffDmg:         int32(le.Uint32(buf[28:32])),
+		OverstackValue:  uint32(le.Uint16(buf[32:34])),
+		SkillID:         uint32(le.Uint16(buf[34:36])),
+		SrcInstID:       le.Uint16(buf[36:38]),
+		DstInstID:       le.Uint16(buf[38:40]),
+		SrcMasterInstID: le.Uint16(buf[40:42]),
+		// 42-50 hold the iss and skar fields, which are unused
+		IFF:          buf[51],
+		Buff:         buf[52],
+		Result:       buf[53],
+		IsActivation: buf[54],
+		IsBuffRemove: buf[55],
+		IsNinety:     buf[56],
+		IsFifty:      buf[57],
+		IsMoving:     buf[58],
+		StateChange:  StateChange(buf[59]),
+		IsFlanking:   buf[60],
+		IsShields:    buf[61],
+		IsOffcycle:   buf[62],
+	}
+}
+
+func decodeEventRevision1(buf []byte) *Event {
+	le := binary.LittleEndian
+	return &Event{
+		Time:            le.Uint64(buf[0:8]),
+		SrcAgent:        le.Uint64(buf[8:16]),
+		DstAgent:        le.Uint64(buf[16:24]),
+		Value:           int32(le.Uint32(buf[24:28])),
+		BuffDmg:         int32(le.Uint32(buf[28:32])),
+		OverstackValue:  le.Uint32(buf[32:36]),
+		SkillID:         le.Uint32(buf[36:40]),
+		SrcInstID:       le.Uint16(buf[40:42]),
+		DstInstID:       le.Uint16(buf[42:44]),
+		SrcMasterInstID: le.Uint16(buf[44:46]),
+		DstMasterInstID: le.Uint16(buf[46:48]),
+		IFF:             buf[48],
+		Buff:            buf[49],
+		Result:          buf[50],
+		IsActivation:    buf[51],
+		IsBuffRemove:    buf[52],
+		IsNinety:        buf[53],
+		IsFifty:         buf[54],
+		IsMoving:        buf[55],
+		StateChange:     StateChange(buf[56]),
+		IsFlanking:      buf[57],
+		IsShields:       buf[58],
+		IsOffcycle:      buf[59],
+	}
+}
@@ -0,0 +1,283 @@
+// Package evtc reads arcdps combat logs (.evtc, .evtc.zip and .zevtc).
+//
+// The format is described in the arcdps readme at https://www.deltaconnected.com/arcdps/evtc/.
+package evtc
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	headerSize     = 16
+	agentSize      = 96
+	agentNameSize  = 64
+	skillSize      = 68
+	skillNameSize  = 64
+	maxAgentCount  = 1 << 20
+	maxSkillCount  = 1 << 20
+	povSearchLimit = 5000
+	// initialTableCapacity is allocated up front for the agent and skill tables, larger ones grow while being read.
+	initialTableCapacity = 4096
+)
+
+// SpeciesWvW is the encounter species id arcdps uses for World vs World logs.
+const SpeciesWvW = 1
+
+var (
+	magic    = []byte("EVTC")
+	zipMagic = []byte("PK\x03\x04")
+
+	ErrNotEvtc = errors.New("not an evtc file")
+)
+
+// Header is the fixed size start of every log.
+type Header struct {
+	// Build is the arcdps build date, e.g. 20240518.
+	Build    string
+	Revision byte
+	// SpeciesID identifies the encounter, usually by the species id of the boss.
+	SpeciesID uint16
+}
+
+// Skill is an entry of the skill table.
+type Skill struct {
+	ID   int32
+	Name string
+}
+
+// Log holds everything of a log except the combat events.
+type Log struct {
+	Header
+	Agents []Agent
+	Skills []Skill
+	// PointOfView is the address of the agent that recorded the log, if known.
+	PointOfView uint64
+}
+
+// Open returns a reader for the uncompressed content of the log file, unpacking zipped logs on the fly.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(file, start); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %v", ErrNotEvtc, err)
+	}
+	if !bytes.Equal(start, zipMagic) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return file, nil
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	archive, err := zip.NewReader(file, stat.Size())
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if len(archive.File) == 0 {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: empty archive", ErrNotEvtc)
+	}
+	content, err := archive.File[0].Open()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &zippedLog{ReadCloser: content, file: file}, nil
+}
+
+type zippedLog struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (z *zippedLog) Close() error {
+	_ = z.ReadCloser.Close()
+	return z.file.Close()
+}
+
+// ReadFile reads the header, agent and skill tables of the log file.
+// The beginning of the event stream is searched for the recording player.
+func ReadFile(path string) (*Log, error) {
+	reader, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	buffered := bufio.NewReader(reader)
+	log, err := Read(buffered)
+	if err != nil {
+		return nil, err
+	}
+	if err := log.findPointOfView(buffered); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// Read reads the header, agent and skill tables from r, leaving r positioned at the start of the combat events.
+func Read(r io.Reader) (*Log, error) {
+	header, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	log := &Log{Header: *header}
+
+	if log.Agents, err = readAgents(r); err != nil {
+		return nil, fmt.Errorf("could not read agents: %w", err)
+	}
+	if log.Skills, err = readSkills(r); err != nil {
+		return nil, fmt.Errorf("could not read skills: %w", err)
+	}
+	return log, nil
+}
+
+func readHeader(r io.Reader) (*Header, error) {
+	buf := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotEvtc, err)
+	}
+	if !bytes.Equal(buf[:len(magic)], magic) {
+		return nil, ErrNotEvtc
+	}
+	return &Header{
+		Build:     string(buf[4:12]),
+		Revision:  buf[12],
+		SpeciesID: binary.LittleEndian.Uint16(buf[13:15]),
+	}, nil
+}
+
+func readAgents(r io.Reader) ([]Agent, error) {
+	count, err := readCount(r, maxAgentCount)
+	if err != nil {
+		return nil, err
+	}
+	// the count is not trusted for the allocation, a corrupt log may claim far more entries than it has
+	agents := make([]Agent, 0, min(count, initialTableCapacity))
+	buf := make([]byte, agentSize)
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		agents = append(agents, decodeAgent(buf))
+	}
+	return agents, nil
+}
+
+func readSkills(r io.Reader) ([]Skill, error) {
+	count, err := readCount(r, maxSkillCount)
+	if err != nil {
+		return nil, err
+	}
+	skills := make([]Skill, 0, min(count, initialTableCapacity))
+	buf := make([]byte, skillSize)
+	for i := uint32(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		skills = append(skills, Skill{
+			ID:   int32(binary.LittleEndian.Uint32(buf[0:4])),
+			Name: cString(buf[4 : 4+skillNameSize]),
+		})
+	}
+	return skills, nil
+}
+
+func readCount(r io.Reader, limit uint32) (uint32, error) {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return 0, err
+	}
+	if count > limit {
+		return 0, fmt.Errorf("implausible table size %d", count)
+	}
+	return count, nil
+}
+
+// findPointOfView searches the first events for the one naming the recording player.
+// arcdps writes it right after the start of the log, so the search is stopped early.
+func (l *Log) findPointOfView(r io.Reader) error {
+	events := NewEventReader(r, l.Revision)
+	for i := 0; i < povSearchLimit; i++ {
+		event, err := events.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if event.StateChange == StateChangePointOfView {
+			l.PointOfView = event.SrcAgent
+			return nil
+		}
+	}
+	return nil
+}
+
+// Agent returns the agent with the given address.
+func (l *Log) Agent(address uint64) *Agent {
+	for i := range l.Agents {
+		if l.Agents[i].Address == address {
+			return &l.Agents[i]
+		}
+	}
+	return nil
+}
+
+// Players returns all player agents.
+func (l *Log) Players() []*Agent {
+	var players []*Agent
+	for i := range l.Agents {
+		if l.Agents[i].IsPlayer() {
+			players = append(players, &l.Agents[i])
+		}
+	}
+	return players
+}
+
+// Boss returns the agent of the encounter's boss, if there is one.
+func (l *Log) Boss() *Agent {
+	if l.SpeciesID == SpeciesWvW {
+		return nil
+	}
+	for i := range l.Agents {
+		if l.Agents[i].IsNPC() && l.Agents[i].SpeciesID() == l.SpeciesID {
+			return &l.Agents[i]
+		}
+	}
+	return nil
+}
+
+// Recorder returns the player that recorded the log, if known.
+func (l *Log) Recorder() *Agent {
+	if l.PointOfView == 0 {
+		return nil
+	}
+	return l.Agent(l.PointOfView)
+}
+
+// cString returns the content of a zero terminated string field.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
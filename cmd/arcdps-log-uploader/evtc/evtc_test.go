@@ -0,0 +1,292 @@
+package evtc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const (
+	testSpecies     = 15438 // Vale Guardian
+	testPlayerAddr  = 0x1001
+	testBossAddr    = 0x2002
+	testGadgetAddr  = 0x3003
+	testPlayerName  = "char\x00:acc.1234\x001\x00"
+	testBuild       = "20240518"
+	testBossName    = "Vale Guardian"
+	testSkillID     = 12345
+	testSkillName   = "Sword Swing"
+	testPlayerProf  = 3
+	testPlayerElite = 55
+)
+
+type fixtureAgent struct {
+	address    uint64
+	profession uint32
+	isElite    uint32
+	name       string
+}
+
+var (
+	testPlayer = fixtureAgent{testPlayerAddr, testPlayerProf, testPlayerElite, testPlayerName}
+	testBoss   = fixtureAgent{testBossAddr, testSpecies, eliteNPC, testBossName}
+	testGadget = fixtureAgent{testGadgetAddr, 0xffff0001, eliteNPC, "Gadget"}
+)
+
+// fixture builds a log in memory.
+type fixture struct {
+	revision byte
+	species  uint16
+	agents   []fixtureAgent
+	events   []Event
+}
+
+func defaultFixture(revision byte) fixture {
+	return fixture{
+		revision: revision,
+		species:  testSpecies,
+		agents:   []fixtureAgent{testPlayer, testBoss, testGadget},
+		events: []Event{
+			{Time: 100, SrcAgent: testPlayerAddr, StateChange: StateChangePointOfView},
+		},
+	}
+}
+
+func (f fixture) bytes() []byte {
+	data := &bytes.Buffer{}
+	data.Write(headerBytes(f.revision, f.species))
+
+	writeCount(data, len(f.agents))
+	for _, agent := range f.agents {
+		data.Write(agentBytes(agent))
+	}
+
+	writeCount(data, 1)
+	skill := make([]byte, skillSize)
+	binary.LittleEndian.PutUint32(skill[0:4], testSkillID)
+	copy(skill[4:], testSkillName)
+	data.Write(skill)
+
+	for _, event := range f.events {
+		data.Write(eventBytes(f.revision, event))
+	}
+	return data.Bytes()
+}
+
+func headerBytes(revision byte, species uint16) []byte {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	copy(header[4:12], testBuild)
+	header[12] = revision
+	binary.LittleEndian.PutUint16(header[13:15], species)
+	return header
+}
+
+func writeCount(data *bytes.Buffer, count int) {
+	_ = binary.Write(data, binary.LittleEndian, uint32(count))
+}
+
+func agentBytes(agent fixtureAgent) []byte {
+	buf := make([]byte, agentSize)
+	binary.LittleEndian.PutUint64(buf[0:8], agent.address)
+	binary.LittleEndian.PutUint32(buf[8:12], agent.profession)
+	binary.LittleEndian.PutUint32(buf[12:16], agent.isElite)
+	copy(buf[28:28+agentNameSize], agent.name)
+	return buf
+}
+
+// eventBytes encodes the fields of the event the package reads, at the offsets of the given revision.
+func eventBytes(revision byte, event Event) []byte {
+	buf := make([]byte, eventSize)
+	le := binary.LittleEndian
+	le.PutUint64(buf[0:8], event.Time)
+	le.PutUint64(buf[8:16], event.SrcAgent)
+	le.PutUint64(buf[16:24], event.DstAgent)
+	le.PutUint32(buf[24:28], uint32(event.Value))
+	if revision == 0 {
+		le.PutUint16(buf[34:36], uint16(event.SkillID))
+		buf[59] = byte(event.StateChange)
+	} else {
+		le.PutUint32(buf[36:40], event.SkillID)
+		buf[56] = byte(event.StateChange)
+	}
+	return buf
+}
+
+func zipBytes(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	data := &bytes.Buffer{}
+	archive := zip.NewWriter(data)
+	entry, err := archive.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	raw := defaultFixture(1).bytes()
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+	}{
+		{"plain", "20240518-201500.evtc", raw},
+		{"zevtc", "20240518-201500.zevtc", zipBytes(t, "20240518-201500.evtc", raw)},
+		{"evtc.zip", "20240518-201500.evtc.zip", zipBytes(t, "20240518-201500.evtc", raw)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log, err := ReadFile(writeFile(t, test.file, test.content))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if log.Build != testBuild || log.Revision != 1 || log.SpeciesID != testSpecies {
+				t.Errorf("header = %+v", log.Header)
+			}
+			if len(log.Agents) != 3 {
+				t.Fatalf("got %d agents, want 3", len(log.Agents))
+			}
+			if len(log.Skills) != 1 || log.Skills[0].ID != testSkillID || log.Skills[0].Name != testSkillName {
+				t.Errorf("skills = %+v", log.Skills)
+			}
+			if boss := log.Boss(); boss == nil || boss.Name != testBossName {
+				t.Errorf("Boss() = %+v", boss)
+			}
+			if recorder := log.Recorder(); recorder == nil || recorder.CharacterName != "char" {
+				t.Errorf("Recorder() = %+v", recorder)
+			}
+		})
+	}
+}
+
+func TestPlayerName(t *testing.T) {
+	agent := decodeAgent(agentBytes(testPlayer))
+	if !agent.IsPlayer() {
+		t.Fatal("agent is no player")
+	}
+	if agent.CharacterName != "char" || agent.AccountName != "acc.1234" || agent.Subgroup != 1 {
+		t.Errorf("got %q, %q, %d", agent.CharacterName, agent.AccountName, agent.Subgroup)
+	}
+	if agent.Profession != testPlayerProf || agent.IsElite != testPlayerElite {
+		t.Errorf("got profession %d, elite %d", agent.Profession, agent.IsElite)
+	}
+
+	boss := decodeAgent(agentBytes(testBoss))
+	if !boss.IsNPC() || boss.IsGadget() || boss.SpeciesID() != testSpecies || boss.CharacterName != "" {
+		t.Errorf("boss = %+v", boss)
+	}
+	gadget := decodeAgent(agentBytes(testGadget))
+	if !gadget.IsGadget() || gadget.IsNPC() {
+		t.Errorf("gadget = %+v", gadget)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	raw := defaultFixture(1).bytes()
+	skillTableEnd := headerSize + 4 + 3*agentSize + 4 + skillSize
+
+	implausible := &bytes.Buffer{}
+	implausible.Write(headerBytes(1, testSpecies))
+	writeCount(implausible, maxAgentCount+1)
+
+	tests := []struct {
+		name    string
+		content []byte
+		notEvtc bool
+		message string
+	}{
+		{"empty", nil, true, ""},
+		{"truncated header", raw[:10], true, ""},
+		{"wrong magic", append([]byte("ABCD"), raw[4:]...), true, ""},
+		{"truncated agents", raw[:headerSize+4+agentSize+10], false, "could not read agents"},
+		{"truncated skills", raw[:skillTableEnd-10], false, "could not read skills"},
+		{"implausible table size", implausible.Bytes(), false, "implausible table size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(test.content))
+			if err == nil {
+				t.Fatal("Read() succeeded")
+			}
+			if test.notEvtc != errors.Is(err, ErrNotEvtc) {
+				t.Errorf("Read() error = %v, ErrNotEvtc expected: %v", err, test.notEvtc)
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("Read() error = %v, want %q", err, test.message)
+			}
+		})
+	}
+}
+
+func TestReadHugeTableCount(t *testing.T) {
+	// a corrupt log claiming the largest plausible agent table, without any entries
+	raw := &bytes.Buffer{}
+	raw.Write(headerBytes(1, testSpecies))
+	writeCount(raw, maxAgentCount)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := Read(bytes.NewReader(raw.Bytes())); err == nil {
+		t.Fatal("Read() succeeded")
+	}
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4<<20 {
+		t.Errorf("Read() allocated %d bytes for a table without entries", allocated)
+	}
+}
+
+func TestReadFileTruncatedEvents(t *testing.T) {
+	// a crash of the game leaves a partial last event behind
+	raw := defaultFixture(0).bytes()
+	log, err := ReadFile(writeFile(t, "truncated.evtc", raw[:len(raw)-20]))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if log.PointOfView != 0 {
+		t.Errorf("PointOfView = %x, the truncated event should be ignored", log.PointOfView)
+	}
+}
+
+func TestEventRevisions(t *testing.T) {
+	want := Event{
+		Time:        123456,
+		SrcAgent:    testBossAddr,
+		DstAgent:    testPlayerAddr,
+		Value:       -42,
+		SkillID:     testSkillID % 0x10000,
+		StateChange: StateChangeHealthUpdate,
+	}
+	for _, revision := range []byte{0, 1} {
+		events := NewEventReader(bytes.NewReader(eventBytes(revision, want)), revision)
+		got, err := events.Next()
+		if err != nil {
+			t.Fatalf("revision %d: Next() error = %v", revision, err)
+		}
+		if *got != want {
+			t.Errorf("revision %d: got %+v, want %+v", revision, *got, want)
+		}
+	}
+}
@@ -28,9 +28,11 @@ func main() {
 }
 
 func start() {
-	model.StartWorkerGroup()
+	settings := model.LoadSettings()
+	settings.Apply()
+	model.StartWorkerGroup(settings.Workers)
 
-	var err = ui.StartUI()
+	var err = ui.StartUI(settings)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/evtc"
+)
+
+// convertLog replaces a raw .evtc log by its compressed .zevtc version on disk, keeping the original if requested.
+// The log continues with the compressed file, if anything goes wrong the raw file is used as it is.
+func convertLog(arcLog *ArcLog, keepOriginal bool) {
+	original := arcLog.File
+	logger := log.WithField("filename", filepath.Base(original))
+
+	compressed, err := evtc.CompressFile(original)
+	if err != nil {
+		logger.Warnf("Could not compress log: %v", err)
+		return
+	}
+	if compressed == original {
+		return
+	}
+	logger.Infof("Compressed log to %v", filepath.Base(compressed))
+	arcLog.File = compressed
+
+	if keepOriginal {
+		return
+	}
+	if err := os.Remove(original); err != nil {
+		logger.Warnf("Could not delete the original log: %v", err)
+	}
+}
+
+// compressForUpload writes a compressed copy of a raw log to a temporary directory, so it is sent with a known size.
+// It returns the path to upload and a function removing the copy again. Compressed logs are uploaded as they are.
+func compressForUpload(path string) (string, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	compressed, err := evtc.IsCompressed(file)
+	if err != nil {
+		return "", nil, err
+	}
+	if compressed {
+		return path, func() {}, nil
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the copy keeps the name of the log, as dps.report shows it
+	dir, err := os.MkdirTemp("", "arcdps-log-uploader-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	target := filepath.Join(dir, evtc.CompressedName(filepath.Base(path)))
+	out, err := os.Create(target)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	err = evtc.Compress(out, file, filepath.Base(path), stat.ModTime())
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return target, cleanup, nil
+}
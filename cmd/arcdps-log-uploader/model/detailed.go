@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/evtc"
+)
+
+// decideDetailed determines whether the log is uploaded as detailed WvW and records the decision on the log.
+func decideDetailed(arcLog *ArcLog, options *UploadOptions) bool {
+	arcLog.DetailedReason = ""
+	if !options.DetailedWvw {
+		arcLog.Detailed = False
+		return false
+	}
+	if reason := detailedNotPossible(arcLog, options.MaxDetailedSize); reason != "" {
+		arcLog.Detailed = ForcedFalse
+		arcLog.DetailedReason = reason
+		return false
+	}
+	arcLog.Detailed = True
+	return true
+}
+
+// detailedNotPossible returns why dps.report would reject a detailed upload of the log,
+// or an empty string if it is accepted.
+func detailedNotPossible(arcLog *ArcLog, maxSize int64) string {
+	if arcLog.Evtc == nil {
+		return "encounter unknown"
+	}
+	if arcLog.Evtc.SpeciesID != evtc.SpeciesWvW {
+		return "not a WvW log"
+	}
+	if maxSize > 0 {
+		if info, err := os.Stat(arcLog.File); err == nil && info.Size() > maxSize {
+			return fmt.Sprintf("larger than %d MB", maxSize/(1024*1024))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+// maxDiscordAttempts limits how often a message is sent again after Discord rejected it as rate limited.
+const maxDiscordAttempts = 5
+
+// DiscordWebhook posts messages to Discord webhooks.
+type DiscordWebhook struct {
+	HTTPClient *http.Client
+}
+
+var Discord = &DiscordWebhook{HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+
+// DiscordMessage is the payload of a webhook execution, also understood by most bots.
+type DiscordMessage struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []DiscordEmbed `json:"embeds,omitempty"`
+}
+
+// DiscordEmbed is a rich message block with a colored border.
+type DiscordEmbed struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Color int    `json:"color"`
+	// Timestamp is shown in the footer in the local time of each reader, in ISO 8601 format.
+	Timestamp string              `json:"timestamp,omitempty"`
+	Fields    []DiscordEmbedField `json:"fields,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Limits of Discord for embeds, counted in characters.
+const (
+	MaxEmbedsPerMessage = 10
+	MaxEmbedFields      = 25
+	MaxEmbedTitle       = 256
+	MaxEmbedFieldName   = 256
+	MaxEmbedFieldValue  = 1024
+	MaxEmbedCharsTotal  = 6000
+)
+
+// Border colors of embeds.
+const (
+	DiscordColorKill    = 0x2ECC71
+	DiscordColorWipe    = 0xE74C3C
+	DiscordColorUnknown = 0x95A5A6
+)
+
+// Length returns the number of characters of the embed that count towards the limit of a message.
+func (e *DiscordEmbed) Length() int {
+	length := utf8.RuneCountInString(e.Title)
+	for _, field := range e.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return length
+}
+
+// discordError is the body Discord responds with if a request failed.
+type discordError struct {
+	Message    string  `json:"message"`
+	RetryAfter float64 `json:"retry_after"`
+}
+
+// PostMessages sends the messages to the webhook in order and reports the outcome of each part (starting with 1) to onPart.
+// Posting stops at the first part that fails, so the remaining parts never arrive out of order.
+func (d *DiscordWebhook) PostMessages(ctx context.Context, webhookURL string, messages []DiscordMessage,
+	onPart func(part int, err error)) error {
+	for i, message := range messages {
+		err := redactWebhookURL(d.postMessage(ctx, webhookURL, message))
+		onPart(i+1, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DiscordWebhook) postMessage(ctx context.Context, webhookURL string, message DiscordMessage) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	// wait for the message to be created, so errors are reported
+	query := u.Query()
+	query.Set("wait", "true")
+	u.RawQuery = query.Encode()
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := d.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && attempt < maxDiscordAttempts {
+			delay := discordRetryAfter(res, body)
+			log.Warnf("Discord rate limited the webhook. Trying again in %v", delay)
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return discordResponseError(res, body)
+		}
+
+		// wait for the bucket to refill before the next message, instead of running into a 429
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			if resetAfter, ok := parseSeconds(res.Header.Get("X-RateLimit-Reset-After")); ok {
+				return sleep(ctx, resetAfter)
+			}
+		}
+		return nil
+	}
+}
+
+// redactWebhookURL removes the webhook URL from errors of the HTTP client, as its path contains the secret token.
+func redactWebhookURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%v discord webhook: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// discordRetryAfter returns how long Discord asks to wait after a rate limited request.
+func discordRetryAfter(res *http.Response, body []byte) time.Duration {
+	var response discordError
+	if err := json.Unmarshal(body, &response); err == nil && response.RetryAfter > 0 {
+		return time.Duration(response.RetryAfter * float64(time.Second))
+	}
+	if resetAfter, ok := parseSeconds(res.Header.Get("X-RateLimit-Reset-After")); ok {
+		return resetAfter
+	}
+	if retryAfter, ok := utils.ParseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+		return retryAfter
+	}
+	return time.Second
+}
+
+func discordResponseError(res *http.Response, body []byte) error {
+	var response discordError
+	if err := json.Unmarshal(body, &response); err == nil && response.Message != "" {
+		return fmt.Errorf("discord responded with %v: %v", res.Status, response.Message)
+	}
+	return fmt.Errorf("discord responded with %v", res.Status)
+}
+
+// parseSeconds parses a number of seconds with fractions, as used in the rate limit headers of Discord.
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPostMessages(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if r.URL.Query().Get("wait") != "true" {
+			t.Errorf("request %d does not wait for the message", requests)
+		}
+		var message DiscordMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("request %d: %v", requests, err)
+		}
+
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.01}`))
+		case 2:
+			received = append(received, message.Content)
+			_, _ = w.Write([]byte(`{"id": "1"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": "Invalid Form Body"}`))
+		}
+	}))
+	defer server.Close()
+
+	webhook := &DiscordWebhook{HTTPClient: server.Client()}
+	messages := []DiscordMessage{{Content: "first"}, {Content: "second"}, {Content: "third"}}
+	var parts []int
+	var partErrors []error
+	err := webhook.PostMessages(context.Background(), server.URL+"/api/webhooks/1/token", messages, func(part int, err error) {
+		parts = append(parts, part)
+		partErrors = append(partErrors, err)
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "Invalid Form Body") {
+		t.Fatalf("PostMessages() error = %v, want the message of Discord", err)
+	}
+	if len(parts) != 2 || parts[0] != 1 || parts[1] != 2 {
+		t.Fatalf("reported parts = %v, want [1 2]", parts)
+	}
+	if partErrors[0] != nil || partErrors[1] == nil {
+		t.Errorf("part errors = %v, want only the second part to fail", partErrors)
+	}
+	if requests != 3 || len(received) != 1 || received[0] != "first" {
+		t.Errorf("requests = %d, received = %v, want the rate limited first part sent again", requests, received)
+	}
+}
+
+func TestPostMessagesRedactsWebhookURL(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	webhookURL := server.URL + "/api/webhooks/1/secret-token"
+	server.Close()
+
+	webhook := &DiscordWebhook{HTTPClient: server.Client()}
+	err := webhook.PostMessages(context.Background(), webhookURL, []DiscordMessage{{Content: "first"}}, func(int, error) {})
+	if err == nil {
+		t.Fatal("PostMessages() succeeded on a closed server")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error %q contains the webhook token", err)
+	}
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const defaultBaseURL = "https://dps.report"
+
+// DpsReportAPI accesses the dps.report endpoints that do not upload logs.
+type DpsReportAPI struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// UploadsPage is a single page of the uploads associated with a user token.
+type UploadsPage struct {
+	Pages        int                 `json:"pages"`
+	TotalUploads int                 `json:"totalUploads"`
+	UserToken    string              `json:"userToken"`
+	Uploads      []DpsReportResponse `json:"uploads"`
+}
+
+var API = &DpsReportAPI{BaseURL: defaultBaseURL, HTTPClient: http.DefaultClient}
+
+// GetUserToken requests a new user token to associate uploads with.
+func (a *DpsReportAPI) GetUserToken(ctx context.Context) (string, error) {
+	var response struct {
+		UserToken string `json:"userToken"`
+	}
+	if err := a.get(ctx, "/getUserToken", nil, &response); err != nil {
+		return "", err
+	}
+	if response.UserToken == "" {
+		return "", fmt.Errorf("dps.report returned an empty user token")
+	}
+	return response.UserToken, nil
+}
+
+// GetUploads returns the given page (starting with 1) of the uploads associated with the user token.
+func (a *DpsReportAPI) GetUploads(ctx context.Context, userToken string, page int) (*UploadsPage, error) {
+	query := url.Values{}
+	query.Set("userToken", userToken)
+	query.Set("page", strconv.Itoa(page))
+
+	uploads := new(UploadsPage)
+	if err := a.get(ctx, "/getUploads", query, uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// GetAllUploads pages through all uploads associated with the user token and passes each page to onPage.
+func (a *DpsReportAPI) GetAllUploads(ctx context.Context, userToken string, onPage func(uploads []DpsReportResponse)) error {
+	for page := 1; ; page++ {
+		uploads, err := a.GetUploads(ctx, userToken, page)
+		if err != nil {
+			return err
+		}
+		onPage(uploads.Uploads)
+		if page >= uploads.Pages || len(uploads.Uploads) == 0 {
+			return nil
+		}
+	}
+}
+
+func (a *DpsReportAPI) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u, err := url.Parse(a.BaseURL + path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	res, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return &statusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not read dps.report response %w", err)
+	}
+	return nil
+}
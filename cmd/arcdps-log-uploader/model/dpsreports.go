@@ -11,7 +11,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"sync"
 	"time"
 
@@ -21,70 +20,315 @@ import (
 )
 
 type Encounter struct {
-	Duration float64 `json:"duration"`
+	UniqueID        string  `json:"uniqueId"`
+	Success         bool    `json:"success"`
+	Duration        float64 `json:"duration"`
+	CompDps         int     `json:"compDps"`
+	NumberOfPlayers int     `json:"numberOfPlayers"`
+	NumberOfGroups  int     `json:"numberOfGroups"`
+	BossID          int     `json:"bossId"`
+	Boss            string  `json:"boss"`
+	IsCm            bool    `json:"isCm"`
+	Gw2Build        int64   `json:"gw2Build"`
+	JSONAvailable   bool    `json:"jsonAvailable"`
+}
+
+type Evtc struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	BossID  int    `json:"bossId"`
+}
+
+type Player struct {
+	DisplayName   string `json:"display_name"`
+	CharacterName string `json:"character_name"`
+	Profession    int    `json:"profession"`
+	EliteSpec     int    `json:"elite_spec"`
+}
+
+// Players maps character names to players. dps.report sends an empty JSON array instead of an object if there are none.
+type Players map[string]Player
+
+func (p *Players) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "[]" {
+		*p = Players{}
+		return nil
+	}
+	players := make(map[string]Player)
+	if err := json.Unmarshal(data, &players); err != nil {
+		return err
+	}
+	*p = players
+	return nil
+}
+
+type ReportInfo struct {
+	Anonymous bool `json:"anonymous"`
+	Detailed  bool `json:"detailed"`
 }
 
 type DpsReportResponse struct {
-	ID            string         `json:"id"`
-	Error         string         `json:"error"`
-	Permalink     string         `json:"permalink"`
-	Encounter     Encounter      `json:"encounter"`
-	EncounterTime utils.JSONTime `json:"encounterTime"`
+	ID               string         `json:"id"`
+	UserToken        string         `json:"userToken"`
+	Error            string         `json:"error"`
+	Permalink        string         `json:"permalink"`
+	UploadTime       utils.JSONTime `json:"uploadTime"`
+	Encounter        Encounter      `json:"encounter"`
+	EncounterTime    utils.JSONTime `json:"encounterTime"`
+	Generator        string         `json:"generator"`
+	GeneratorID      int            `json:"generatorId"`
+	GeneratorVersion int            `json:"generatorVersion"`
+	Language         string         `json:"language"`
+	LanguageID       int            `json:"languageId"`
+	Evtc             Evtc           `json:"evtc"`
+	Players          Players        `json:"players"`
+	Report           ReportInfo     `json:"report"`
 }
 
-var client = utils.NewRateLimitedClient(rate.NewLimiter(rate.Every(10*time.Second), 45))
-var rateLimitedUntil *time.Time
+// responseTimeout limits how long dps.report may take to answer after the log was sent, before a mirror is tried.
+const responseTimeout = 5 * time.Minute
+
+const rateLimitStateFileName = "ratelimit.json"
 
-var UploadQueue = make(chan QueueEntry, 1000)
+var client = utils.NewRateLimitedClient(newUploadHTTPClient(),
+	utils.NewRateLimiter(rate.NewLimiter(rate.Every(10*time.Second), 45), rateLimitStateFileName))
+
+var UploadQueue = NewQueue()
 var wg sync.WaitGroup
 
 type UploadOptions struct {
 	DetailedWvw bool
 	Anonymous   bool
+	MaxRetries  int
+	UserToken   string
+	// Hosts are the dps.report hosts to upload to, in order of preference.
+	Hosts []string
+	// FetchEliteInsights loads the Elite Insights JSON of each report after the upload.
+	FetchEliteInsights bool
+	// MaxDetailedSize is the largest log file uploaded as detailed WvW. Zero means no limit.
+	MaxDetailedSize int64
+	// ConvertEvtc replaces raw .evtc logs by compressed .zevtc logs on disk before they are uploaded.
+	ConvertEvtc bool
+	// KeepOriginalEvtc keeps the raw log next to the compressed one.
+	KeepOriginalEvtc bool
+	// MinDuration skips logs whose combat time, as read from the log file, is shorter. Zero disables the check.
+	MinDuration time.Duration
 }
 
 type QueueEntry struct {
+	Context  context.Context
 	ArcLog   *ArcLog
 	Options  *UploadOptions
+	Force    bool
 	OnDone   func(*DpsReportResponse, error)
 	OnChange func()
 }
 
-func StartWorkerGroup() {
+func StartWorkerGroup(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
 	// start the worker
-	for i := 0; i < 5; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go worker(UploadQueue)
 	}
 }
 
 func CloseQueue() {
-	close(UploadQueue)
+	UploadQueue.Close()
 }
 
-func worker(jobChan <-chan QueueEntry) {
-	for job := range jobChan {
+func worker(queue *Queue) {
+	defer wg.Done()
+	for {
+		job, ok := queue.Pop()
+		if !ok {
+			return
+		}
+
 		options := job.Options
 		file := job.ArcLog.File
 
-		report, err := uploadFile(file, options, func(status LogStatus) {
+		if job.Context.Err() != nil {
+			job.OnDone(nil, job.Context.Err())
+			continue
+		}
+
+		// the analysis still reads the original file, which may be replaced by the conversion
+		if err := job.ArcLog.WaitAnalyzed(job.Context); err != nil {
+			job.OnDone(nil, err)
+			continue
+		}
+		if options.ConvertEvtc {
+			convertLog(job.ArcLog, options.KeepOriginalEvtc)
+			file = job.ArcLog.File
+		}
+
+		if reuseHistory(&job) {
+			continue
+		}
+
+		if encounter := job.ArcLog.Encounter; encounter != nil {
+			if reason := TooShort(encounter.Duration, options.MinDuration); reason != "" {
+				log.WithField("filename", filepath.Base(file)).Debugf("Skipping log: %v", reason)
+				job.OnDone(nil, &SkippedError{Reason: reason})
+				continue
+			}
+		}
+
+		// the queued options are shared with other logs, the decision only applies to this one
+		logOptions := *options
+		logOptions.DetailedWvw = decideDetailed(job.ArcLog, options)
+		job.OnChange()
+
+		report, host, err := uploadFile(job.Context, file, &logOptions, func(status LogStatus) {
 			job.ArcLog.Status = status
 			job.OnChange()
+		}, func(percent int) {
+			job.ArcLog.UploadProgress = percent
+			job.OnChange()
 		})
-		if job.ArcLog.Detailed == True && !options.DetailedWvw {
-			job.ArcLog.Detailed = ForcedFalse
+		job.ArcLog.UploadHost = host
+		if err == nil && report.Permalink != "" && job.ArcLog.Hash != "" {
+			history.Store(job.ArcLog.Hash, HistoryEntry{
+				Report:     *report,
+				Anonymous:  options.Anonymous,
+				Detailed:   logOptions.DetailedWvw,
+				Host:       host,
+				UploadedAt: time.Now(),
+			})
 		}
 		job.OnDone(report, err)
+		if err == nil {
+			fetchEliteInsights(&job, report)
+		}
+	}
+}
+
+// fetchEliteInsights loads the Elite Insights data of the report onto the log, if requested by the upload options.
+func fetchEliteInsights(job *QueueEntry, report *DpsReportResponse) {
+	if !job.Options.FetchEliteInsights || !report.Encounter.JSONAvailable {
+		return
+	}
+	eliteInsights, err := LoadEliteInsights(job.Context, job.ArcLog.UploadHost, report)
+	if err != nil {
+		log.WithField("filename", filepath.Base(job.ArcLog.File)).Warnf("Could not load Elite Insights data: %v", err)
+		return
 	}
+	job.ArcLog.EliteInsights = eliteInsights
+	job.OnChange()
 }
 
-func uploadFile(path string, options *UploadOptions, callback func(status LogStatus)) (*DpsReportResponse, error) {
+// reuseHistory completes the job with a previous upload of the same log content, unless a new upload is forced.
+func reuseHistory(job *QueueEntry) bool {
+	hash, err := HashFile(job.ArcLog.File)
+	if err != nil {
+		log.WithField("filename", filepath.Base(job.ArcLog.File)).Warnf("Could not hash log file: %v", err)
+		return false
+	}
+	job.ArcLog.Hash = hash
+	job.ArcLog.FromHistory = false
+
+	if job.Force {
+		return false
+	}
+	entry, found := history.Lookup(hash)
+	if !found {
+		return false
+	}
+	// a report uploaded with other settings does not replace the upload requested now
+	if entry.Anonymous != job.Options.Anonymous || entry.Detailed != decideDetailed(job.ArcLog, job.Options) {
+		return false
+	}
+
+	log.WithField("filename", filepath.Base(job.ArcLog.File)).Infof("Log was already uploaded at %v", entry.UploadedAt)
+	job.ArcLog.FromHistory = true
+	job.ArcLog.UploadHost = entry.Host
+	job.ArcLog.Anonymized = entry.Anonymous
+	job.ArcLog.DetailedReason = ""
+	if entry.Detailed {
+		job.ArcLog.Detailed = True
+	} else {
+		job.ArcLog.Detailed = False
+	}
+	report := entry.Report
+	job.OnDone(&report, nil)
+	fetchEliteInsights(job, &report)
+	return true
+}
+
+func uploadFile(ctx context.Context, path string, options *UploadOptions, callback func(status LogStatus),
+	onProgress func(percent int)) (*DpsReportResponse, string, error) {
 	filename := filepath.Base(path)
 	logger := log.WithField("filename", filename)
 
 	logger.Info("Uploading File ", path)
 
-	responseBody, err := doRequest(callback, path, options, logger)
+	// raw logs are sent compressed, as .zevtc logs are a fraction of their size
+	uploadPath, cleanup, err := compressForUpload(path)
+	if err != nil {
+		logger.Warnf("Could not compress log, uploading it as it is: %v", err)
+		uploadPath = path
+	} else {
+		defer cleanup()
+	}
+
+	return doRequest(ctx, callback, onProgress, uploadPath, options, logger)
+}
+
+func doRequest(ctx context.Context, callback func(status LogStatus), onProgress func(percent int), path string,
+	options *UploadOptions, logger *log.Entry) (*DpsReportResponse, string, error) {
+	for attempt := 1; ; attempt++ {
+		report, host, err := requestReportWithFailover(ctx, callback, onProgress, path, options, logger)
+		if err == nil {
+			return report, host, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		maxAttempts := maxAttemptsFor(err, options.MaxRetries)
+		if attempt >= maxAttempts {
+			return nil, "", attemptsError(err, attempt)
+		}
+
+		delay := retryDelay(attempt)
+		logger.Warnf("Upload attempt %d of %d failed: %v. Trying again in %v", attempt, maxAttempts, err, delay)
+		callback(WaitingRetry)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return nil, "", sleepErr
+		}
+	}
+}
+
+// requestReportWithFailover uploads the log to the first host, moving on to the next one while hosts are overloaded or unreachable.
+func requestReportWithFailover(ctx context.Context, callback func(status LogStatus), onProgress func(percent int), path string,
+	options *UploadOptions, logger *log.Entry) (*DpsReportResponse, string, error) {
+	hosts := options.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{API.BaseURL}
+	}
+
+	var err error
+	for i, host := range hosts {
+		var report *DpsReportResponse
+		report, err = requestReport(ctx, callback, onProgress, host, path, options, logger)
+		if err == nil {
+			return report, host, nil
+		}
+		if ctx.Err() != nil || !shouldFailover(err) {
+			return nil, host, err
+		}
+		if i+1 < len(hosts) {
+			logger.Warnf("Upload to %v failed: %v. Failing over to %v", host, err, hosts[i+1])
+		}
+	}
+	return nil, "", err
+}
+
+func requestReport(ctx context.Context, callback func(status LogStatus), onProgress func(percent int), host, path string,
+	options *UploadOptions, logger *log.Entry) (*DpsReportResponse, error) {
+	responseBody, err := doRequestInternal(ctx, callback, onProgress, host, path, options, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -96,69 +340,76 @@ func uploadFile(path string, options *UploadOptions, callback func(status LogSta
 		return nil, fmt.Errorf("could not read dps.report response %w", jsonErr)
 	}
 
+	if uploadErr := checkResponse(&dpsReportResponse); uploadErr != nil {
+		logger.Errorf("dps.report could not process the log: %v (%q)", uploadErr, dpsReportResponse.Error)
+		return nil, uploadErr
+	}
 	return &dpsReportResponse, nil
 }
 
-func doRequest(callback func(status LogStatus), path string, options *UploadOptions, logger *log.Entry) ([]byte, error) {
-	return doRequestInternal(callback, path, options, logger)
-}
+func doRequestInternal(ctx context.Context, callback func(status LogStatus), onProgress func(percent int), host, path string,
+	options *UploadOptions, logger *log.Entry) ([]byte, error) {
+	if client.RateLimiter.Blocked() {
+		callback(WaitingRateLimitingHard)
+	} else {
+		callback(WaitingRateLimiting)
+	}
 
-func doRequestInternal(callback func(status LogStatus), path string, options *UploadOptions, logger *log.Entry) ([]byte, error) {
-	req, err := buildRequest(path, options)
+	req, err := buildRequest(ctx, host, path, options, onProgress)
 	if err != nil {
 		return nil, err
 	}
 
-	callback(WaitingRateLimiting)
-
-	waitUntilUnbanned()
-
 	res, err := client.Do(req, func() { callback(Uploading) })
 	if err != nil {
-		logger.Fatal(err)
+		logger.Errorf("Request to dps.report failed: %v", err)
+		return nil, err
 	}
 	defer func() { _ = res.Body.Close() }()
-	if res.StatusCode == 429 {
-		retryAfter, _ := strconv.Atoi(res.Header.Get("Retry-After"))
-		logger.Warnf("Request Rate Limited. Trying again in %v", retryAfter)
-		callback(WaitingRateLimitingHard)
-
-		timeToUnban := time.Duration(retryAfter+2) * time.Second
-		freeTime := time.Now().Add(timeToUnban)
-		rateLimitedUntil = &freeTime
-		time.Sleep(timeToUnban)
-		return doRequestInternal(callback, path, options, logger)
-	}
-	if res.StatusCode == 500 {
-		if options.DetailedWvw {
-			logger.Warnf("Upload failed due to server error. Trying again without detailed wvw")
-			options.DetailedWvw = false
-			return doRequestInternal(callback, path, options, logger)
-		}
+	if res.StatusCode == http.StatusTooManyRequests {
+		// the limiter pauses all workers until the server accepts requests again
+		logger.Warnf("Request Rate Limited. Trying again at %v", client.RateLimiter.BlockedUntil().Format(time.TimeOnly))
+		return doRequestInternal(ctx, callback, onProgress, host, path, options, logger)
 	}
 	if res.StatusCode != 200 {
 		logger.Errorf("dps.report responded with status %v (%v). Header: %v", res.StatusCode, res.Status, res.Header)
-		return nil, fmt.Errorf("upload failed: %v", res.Status)
+		return nil, &statusError{StatusCode: res.StatusCode, Status: res.Status}
 	}
 	responseBody, readErr := io.ReadAll(res.Body)
 	if readErr != nil {
-		logger.Fatal(readErr)
+		logger.Errorf("Could not read dps.report response: %v", readErr)
+		return nil, readErr
 	}
 	return responseBody, nil
 }
 
-func waitUntilUnbanned() {
-	if rateLimitedUntil != nil {
-		if rateLimitedUntil.After(time.Now()) {
-			sub := time.Until(*rateLimitedUntil)
-			log.Debugf("Waiting to be unblocked (in %v)", sub)
-			<-time.After(sub)
-		}
+// sleep waits for the given duration or until the context is cancelled, whichever happens first.
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-func buildRequest(path string, options *UploadOptions) (*http.Request, error) {
-	requestURL, urlErr := buildURL(options)
+// uploadBody streams the multipart request body and closes the underlying log file once the transport is done with it.
+type uploadBody struct {
+	io.Reader
+	file *os.File
+}
+
+func (b *uploadBody) Close() error {
+	return b.file.Close()
+}
+
+// buildRequest creates a multipart upload request whose body is streamed from disk instead of being buffered in memory.
+func buildRequest(ctx context.Context, host, path string, options *UploadOptions,
+	onProgress func(percent int)) (*http.Request, error) {
+	requestURL, urlErr := buildURL(host, options)
 	if urlErr != nil {
 		return nil, urlErr
 	}
@@ -167,38 +418,53 @@ func buildRequest(path string, options *UploadOptions) (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("file", filepath.Base(file.Name()))
+	stat, err := file.Stat()
 	if err != nil {
+		_ = file.Close()
 		return nil, err
 	}
-	_, err = io.Copy(part, file)
+
+	// render the multipart framing around the file content up front, so the content length is known
+	framing := &bytes.Buffer{}
+	writer := multipart.NewWriter(framing)
+	_, err = writer.CreateFormFile("file", filepath.Base(path))
 	if err != nil {
+		_ = file.Close()
 		return nil, err
 	}
+	head := bytes.Clone(framing.Bytes())
+	framing.Reset()
 	err = writer.Close()
 	if err != nil {
+		_ = file.Close()
 		return nil, err
 	}
-	err = file.Close()
-	if err != nil {
-		return nil, err
+	tail := framing.Bytes()
+
+	onProgress(0)
+	body := &uploadBody{
+		Reader: io.MultiReader(
+			bytes.NewReader(head),
+			utils.NewProgressReader(file, stat.Size(), onProgress),
+			bytes.NewReader(tail),
+		),
+		file: file,
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, requestURL.String(), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL.String(), body)
 	if err != nil {
+		_ = body.Close()
 		return nil, err
 	}
+	req.ContentLength = int64(len(head)) + stat.Size() + int64(len(tail))
 	req.Header.Add("Content-Type", writer.FormDataContentType())
 	return req, nil
 }
 
-func buildURL(options *UploadOptions) (*url.URL, error) {
-	u, err := url.Parse("https://dps.report/uploadContent?json=1&generator=ei")
+func buildURL(host string, options *UploadOptions) (*url.URL, error) {
+	u, err := url.Parse(host + "/uploadContent?json=1&generator=ei")
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	q := u.Query()
 
@@ -213,6 +479,16 @@ func buildURL(options *UploadOptions) (*url.URL, error) {
 	} else {
 		q.Set("anonymous", "false")
 	}
+
+	if options.UserToken != "" {
+		q.Set("userToken", options.UserToken)
+	}
 	u.RawQuery = q.Encode()
 	return u, err
 }
+
+func newUploadHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseTimeout
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,216 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+const eliteInsightsCacheDir = "ei"
+
+// reportIDPattern matches the ids dps.report hands out, which are used as cache file names.
+var reportIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// EliteInsights is the commonly used subset of the Elite Insights JSON output.
+type EliteInsights struct {
+	EliteInsightsVersion string       `json:"eliteInsightsVersion"`
+	FightName            string       `json:"fightName"`
+	Duration             string       `json:"duration"`
+	DurationMS           int64        `json:"durationMS"`
+	Success              bool         `json:"success"`
+	IsCM                 bool         `json:"isCM"`
+	RecordedBy           string       `json:"recordedBy"`
+	Players              []EIPlayer   `json:"players"`
+	Targets              []EITarget   `json:"targets"`
+	Phases               []EIPhase    `json:"phases"`
+	Mechanics            []EIMechanic `json:"mechanics"`
+}
+
+type EIPlayer struct {
+	Name            string           `json:"name"`
+	Account         string           `json:"account"`
+	Profession      string           `json:"profession"`
+	Group           int              `json:"group"`
+	HasCommanderTag bool             `json:"hasCommanderTag"`
+	DpsAll          []EIDps          `json:"dpsAll"`
+	DpsTargets      [][]EIDps        `json:"dpsTargets"`
+	BuffUptimes     []EIBuffUptime   `json:"buffUptimes"`
+	Defenses        []EIDefenseStats `json:"defenses"`
+}
+
+type EIDps struct {
+	Dps            int     `json:"dps"`
+	Damage         int     `json:"damage"`
+	CondiDps       int     `json:"condiDps"`
+	CondiDamage    int     `json:"condiDamage"`
+	PowerDps       int     `json:"powerDps"`
+	PowerDamage    int     `json:"powerDamage"`
+	BreakbarDamage float64 `json:"breakbarDamage"`
+}
+
+type EIDefenseStats struct {
+	DamageTaken int `json:"damageTaken"`
+	DownCount   int `json:"downCount"`
+	DeadCount   int `json:"deadCount"`
+}
+
+type EIBuffUptime struct {
+	ID       int          `json:"id"`
+	BuffData []EIBuffData `json:"buffData"`
+}
+
+type EIBuffData struct {
+	Uptime   float64 `json:"uptime"`
+	Presence float64 `json:"presence"`
+}
+
+type EITarget struct {
+	ID                  int     `json:"id"`
+	Name                string  `json:"name"`
+	TotalHealth         int     `json:"totalHealth"`
+	FinalHealth         int     `json:"finalHealth"`
+	HealthPercentBurned float64 `json:"healthPercentBurned"`
+	IsFake              bool    `json:"isFake"`
+	DpsAll              []EIDps `json:"dpsAll"`
+}
+
+type EIPhase struct {
+	Name          string `json:"name"`
+	Start         int64  `json:"start"`
+	End           int64  `json:"end"`
+	Targets       []int  `json:"targets"`
+	BreakbarPhase bool   `json:"breakbarPhase"`
+}
+
+type EIMechanic struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	MechanicsData []EIMechanicEvent `json:"mechanicsData"`
+}
+
+type EIMechanicEvent struct {
+	Time  int64  `json:"time"`
+	Actor string `json:"actor"`
+}
+
+// SquadDps returns the summed up dps of all players over the whole fight.
+func (ei *EliteInsights) SquadDps() int {
+	dps := 0
+	for _, player := range ei.Players {
+		if len(player.DpsAll) > 0 {
+			dps += player.DpsAll[0].Dps
+		}
+	}
+	return dps
+}
+
+// MainTarget returns the first target that is not a fake target created by Elite Insights.
+func (ei *EliteInsights) MainTarget() *EITarget {
+	for i := range ei.Targets {
+		if !ei.Targets[i].IsFake {
+			return &ei.Targets[i]
+		}
+	}
+	return nil
+}
+
+// BossHealthLeft returns the remaining health of the main target in percent.
+func (ei *EliteInsights) BossHealthLeft() (float64, bool) {
+	target := ei.MainTarget()
+	if target == nil {
+		return 0, false
+	}
+	return 100 - target.HealthPercentBurned, true
+}
+
+// BoonUptime returns the average uptime of the buff with the given id across all players over the whole fight.
+func (ei *EliteInsights) BoonUptime(buffID int) float64 {
+	var sum float64
+	for _, player := range ei.Players {
+		for _, uptime := range player.BuffUptimes {
+			if uptime.ID == buffID && len(uptime.BuffData) > 0 {
+				sum += uptime.BuffData[0].Uptime
+			}
+		}
+	}
+	if len(ei.Players) == 0 {
+		return 0
+	}
+	return sum / float64(len(ei.Players))
+}
+
+// GetJSON returns the raw Elite Insights JSON of the report with the given id from the host it was uploaded to.
+// An empty host means the configured one.
+func (a *DpsReportAPI) GetJSON(ctx context.Context, host, reportID string) ([]byte, error) {
+	if host == "" {
+		host = a.BaseURL
+	}
+	query := url.Values{}
+	query.Set("id", reportID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/getJson?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &statusError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+	return io.ReadAll(res.Body)
+}
+
+// LoadEliteInsights returns the Elite Insights data of the report, fetching it from the host the report was uploaded to
+// unless it is cached already.
+func LoadEliteInsights(ctx context.Context, host string, report *DpsReportResponse) (*EliteInsights, error) {
+	if report.ID == "" {
+		return nil, fmt.Errorf("report has no id")
+	}
+	if !reportIDPattern.MatchString(report.ID) {
+		return nil, fmt.Errorf("invalid report id %q", report.ID)
+	}
+	cacheFile, err := utils.ConfigFile(filepath.Join(eliteInsightsCacheDir, report.ID+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(cacheFile)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = API.GetJSON(ctx, host, report.ID)
+		if err != nil {
+			return nil, err
+		}
+		if mkdirErr := os.MkdirAll(filepath.Dir(cacheFile), 0o700); mkdirErr != nil {
+			return nil, mkdirErr
+		}
+		if writeErr := utils.WriteFileAtomic(cacheFile, data); writeErr != nil {
+			log.Warnf("Could not cache Elite Insights data of %v: %v", report.ID, writeErr)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	eliteInsights := new(EliteInsights)
+	if err := json.Unmarshal(data, eliteInsights); err != nil {
+		// the next load fetches the data again instead of failing on the same file forever
+		if removeErr := os.Remove(cacheFile); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			log.Warnf("Could not remove the cached Elite Insights data of %v: %v", report.ID, removeErr)
+		}
+		return nil, fmt.Errorf("could not read Elite Insights data %w", err)
+	}
+	return eliteInsights, nil
+}
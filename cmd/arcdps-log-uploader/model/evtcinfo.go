@@ -0,0 +1,137 @@
+package model
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/evtc"
+)
+
+// ReadLogHeader fills the log with the encounter details found in the header of the log file,
+// so they are known before the log is uploaded.
+func ReadLogHeader(arcLog *ArcLog) error {
+	header, err := evtc.ReadFile(arcLog.File)
+	if err != nil {
+		return err
+	}
+	// the skill table is not needed later on, but large
+	header.Skills = nil
+	arcLog.Evtc = header
+
+	arcLog.BossID = int(header.SpeciesID)
+	if boss := header.Boss(); boss != nil {
+		arcLog.Boss = boss.Name
+	} else if header.SpeciesID == evtc.SpeciesWvW {
+		arcLog.Boss = "World vs World"
+	}
+	if recorder := header.Recorder(); recorder != nil {
+		arcLog.RecordedBy = recorder.CharacterName
+	}
+
+	agents := header.Players()
+	players := make([]Player, 0, len(agents))
+	for _, agent := range agents {
+		players = append(players, Player{
+			DisplayName:   agent.AccountName,
+			CharacterName: agent.CharacterName,
+			Profession:    int(agent.Profession),
+			EliteSpec:     int(agent.IsElite),
+		})
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].CharacterName < players[j].CharacterName
+	})
+	arcLog.Players = players
+	return nil
+}
+
+// analysisSlots limits how many logs are analyzed at the same time, as each analysis reads the whole file.
+var analysisSlots = make(chan struct{}, 2)
+
+// ReadLogAsync reads the header of the log file and then determines the outcome of the encounter in the background.
+// onHeader is called once the header has been read, onDone once the analysis has finished, also if either failed.
+func ReadLogAsync(arcLog *ArcLog, onHeader func(), onDone func()) {
+	done := make(chan struct{})
+	arcLog.mu.Lock()
+	arcLog.analyzed = done
+	arcLog.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		if err := ReadLogHeader(arcLog); err != nil {
+			log.Warnf("Could not read header of %v: %v", filepath.Base(arcLog.File), err)
+			onHeader()
+			onDone()
+			return
+		}
+		onHeader()
+
+		analysisSlots <- struct{}{}
+		err := AnalyzeLog(arcLog)
+		<-analysisSlots
+		if err != nil {
+			log.Warnf("Could not analyze %v: %v", filepath.Base(arcLog.File), err)
+		}
+		onDone()
+	}()
+}
+
+// AnalyzeLog determines the outcome of the encounter from the combat events of the log file.
+// The result of an uploaded report takes precedence over the local one.
+func AnalyzeLog(arcLog *ArcLog) error {
+	_, encounter, err := evtc.AnalyzeFile(arcLog.File)
+	if err != nil {
+		return err
+	}
+	arcLog.Encounter = encounter
+	if arcLog.Report == nil && arcLog.Evtc != nil && arcLog.Evtc.SpeciesID != evtc.SpeciesWvW {
+		if encounter.Success() {
+			arcLog.Result = ResultKill
+		} else {
+			arcLog.Result = ResultWipe
+		}
+	}
+	return nil
+}
+
+// WaitAnalyzed blocks until the header of the log has been read and a running analysis has finished or the context is done.
+func (l *ArcLog) WaitAnalyzed(ctx context.Context) error {
+	l.mu.Lock()
+	analyzed := l.analyzed
+	l.mu.Unlock()
+
+	if analyzed == nil {
+		return nil
+	}
+	select {
+	case <-analyzed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// EncounterTime returns the time the encounter started, either from the report or from the log itself.
+func (l *ArcLog) EncounterTime() (time.Time, bool) {
+	if l.Report != nil && !time.Time(l.Report.EncounterTime).IsZero() {
+		return time.Time(l.Report.EncounterTime), true
+	}
+	if l.Encounter != nil && !l.Encounter.Start.IsZero() {
+		return l.Encounter.Start, true
+	}
+	return time.Time{}, false
+}
+
+// CombatDuration returns the duration of the encounter, either from the report or from the log itself.
+func (l *ArcLog) CombatDuration() (time.Duration, bool) {
+	if l.Report != nil && l.Report.Encounter.Duration > 0 {
+		return time.Duration(l.Report.Encounter.Duration * float64(time.Second)), true
+	}
+	if l.Encounter != nil && l.Encounter.Duration > 0 {
+		return l.Encounter.Duration, true
+	}
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FolderRule includes or excludes logs by the name of the folder arcdps stored them in.
+// arcdps sorts logs into one folder per encounter, named after the boss or its species id.
+type FolderRule struct {
+	Exclude bool   `json:"exclude"`
+	Pattern string `json:"pattern"`
+}
+
+func (r FolderRule) String() string {
+	if r.Exclude {
+		return "-" + r.Pattern
+	}
+	return "+" + r.Pattern
+}
+
+// matches reports whether the glob pattern matches the folder name, ignoring case.
+func (r FolderRule) matches(folder string) bool {
+	matched, err := filepath.Match(strings.ToLower(r.Pattern), strings.ToLower(folder))
+	if err != nil {
+		log.Warnf("Invalid folder rule %v: %v", r, err)
+		return false
+	}
+	return matched
+}
+
+// ParseFolderRules reads one rule per line. Lines starting with "+" include, all others exclude matching folders.
+func ParseFolderRules(lines []string) []FolderRule {
+	var rules []FolderRule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "+"):
+			rules = append(rules, FolderRule{Pattern: strings.TrimSpace(line[1:])})
+		case strings.HasPrefix(line, "-"):
+			rules = append(rules, FolderRule{Exclude: true, Pattern: strings.TrimSpace(line[1:])})
+		case line != "":
+			rules = append(rules, FolderRule{Exclude: true, Pattern: line})
+		}
+	}
+	return rules
+}
+
+// ExcludedBy returns the reason the log file is excluded by the rules, or an empty string if it should be uploaded.
+// Exclude rules take precedence. If there are include rules, one of them has to match.
+func ExcludedBy(rules []FolderRule, path string) string {
+	folders := encounterFolders(path)
+
+	hasIncludes := false
+	included := false
+	for _, rule := range rules {
+		if !rule.Exclude {
+			hasIncludes = true
+		}
+		for _, folder := range folders {
+			if !rule.matches(folder) {
+				continue
+			}
+			if rule.Exclude {
+				return "excluded by " + rule.String()
+			}
+			included = true
+		}
+	}
+	if hasIncludes && !included {
+		return "not included by any rule"
+	}
+	return ""
+}
+
+// encounterFolders returns the names of the folders that may be the encounter folder of the log.
+// Besides the direct parent, arcdps can be configured to add a folder per recording character below the encounter folder.
+func encounterFolders(path string) []string {
+	parent := filepath.Dir(path)
+	return []string{filepath.Base(parent), filepath.Base(filepath.Dir(parent))}
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+const historyFileName = "history.json"
+
+// HistoryEntry is the result of a previous upload of a log file.
+type HistoryEntry struct {
+	Report     DpsReportResponse `json:"report"`
+	Anonymous  bool              `json:"anonymous"`
+	Detailed   bool              `json:"detailed"`
+	Host       string            `json:"host,omitempty"`
+	UploadedAt time.Time         `json:"uploadedAt"`
+}
+
+// History remembers uploaded logs across restarts, keyed by the SHA-256 of the log file content.
+type History struct {
+	mu      sync.Mutex
+	once    sync.Once
+	entries map[string]HistoryEntry
+}
+
+var history = new(History)
+
+func (h *History) load() {
+	h.once.Do(func() {
+		h.entries = make(map[string]HistoryEntry)
+		if err := utils.LoadJSON(historyFileName, &h.entries); err != nil {
+			log.Errorf("Could not load upload history: %v", err)
+		}
+	})
+}
+
+// Lookup returns the previous upload of the log with the given content hash.
+func (h *History) Lookup(hash string) (HistoryEntry, bool) {
+	h.load()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, found := h.entries[hash]
+	return entry, found
+}
+
+// Store records the upload of the log with the given content hash and persists the history.
+func (h *History) Store(hash string, entry HistoryEntry) {
+	h.load()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries[hash] = entry
+	if err := utils.SaveJSON(historyFileName, h.entries); err != nil {
+		log.Errorf("Could not save upload history: %v", err)
+	}
+}
+
+// HashFile returns the hex encoded SHA-256 of the file content.
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+// arcdpsFileTimeLayout is the time format arcdps uses for the names of its log files, e.g. 20240518-203512.zevtc.
+const arcdpsFileTimeLayout = "20060102-150405"
+
+// FindLogs returns the log files accepted by match below dir that were recorded in the time range [from, to).
+// Hidden and symlinked directories as well as unreadable entries are skipped.
+func FindLogs(dir string, from, to time.Time, match func(path string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil //nolint:nilerr // unreadable entries are skipped, the import continues with the others
+		}
+		if entry.IsDir() {
+			if path != dir && utils.IsHidden(path, entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 || !match(path) {
+			return nil
+		}
+
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil //nolint:nilerr // the file vanished in between
+		}
+		recorded := LogTime(path, info)
+		if !recorded.Before(from) && recorded.Before(to) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// LogTime returns the time the log was recorded. The timestamp arcdps puts into the file name is preferred,
+// as the modification time changes when logs are copied around.
+func LogTime(path string, info fs.FileInfo) time.Time {
+	name := filepath.Base(path)
+	if len(name) >= len(arcdpsFileTimeLayout) {
+		if recorded, err := time.ParseInLocation(arcdpsFileTimeLayout, name[:len(arcdpsFileTimeLayout)], time.Local); err == nil {
+			return recorded
+		}
+	}
+	return info.ModTime()
+}
+
+// DailyReset returns the most recent Guild Wars 2 daily reset, which happens at midnight UTC.
+func DailyReset(now time.Time) time.Time {
+	utc := now.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+}
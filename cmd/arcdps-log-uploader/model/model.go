@@ -1,15 +1,27 @@
 package model
 
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/evtc"
+)
+
 type LogStatus int
 
 const (
 	Outstanding LogStatus = iota
 	WaitingInQueue
+	Paused
 	WaitingRateLimiting
 	WaitingRateLimitingHard
 	Uploading
+	WaitingRetry
 	Done
 	Error
+	Cancelled
+	Skipped
 )
 
 type DetailedStatus int
@@ -20,12 +32,97 @@ const (
 	ForcedFalse
 )
 
+type EncounterResult int
+
+const (
+	ResultUnknown EncounterResult = iota
+	ResultKill
+	ResultWipe
+)
+
 type ArcLog struct {
-	Checked      bool
-	File         string
-	Status       LogStatus
-	ErrorMessage error
-	Report       *DpsReportResponse
-	Detailed     DetailedStatus
-	Anonymized   bool
+	Checked        bool
+	File           string
+	Status         LogStatus
+	UploadProgress int
+	ErrorMessage   error
+	SkipReason     string
+	Report         *DpsReportResponse
+	Detailed       DetailedStatus
+	DetailedReason string
+	Anonymized     bool
+	Hash           string
+	FromHistory    bool
+	Boss           string
+	BossID         int
+	Result         EncounterResult
+	IsCM           bool
+	Players        []Player
+	RecordedBy     string
+	Evtc           *evtc.Log
+	Encounter      *evtc.Encounter
+	EliteInsights  *EliteInsights
+	UploadHost     string
+
+	mu           sync.Mutex
+	cancelUpload context.CancelFunc
+	analyzed     chan struct{}
+}
+
+// SetReport stores the dps.report response and takes over the encounter details it contains.
+func (l *ArcLog) SetReport(report *DpsReportResponse) {
+	l.Report = report
+	if report == nil {
+		return
+	}
+
+	if report.Encounter.Boss != "" {
+		l.Boss = report.Encounter.Boss
+	}
+	if report.Encounter.BossID != 0 {
+		l.BossID = report.Encounter.BossID
+	} else if report.Evtc.BossID != 0 {
+		l.BossID = report.Evtc.BossID
+	}
+	l.IsCM = report.Encounter.IsCm
+	if report.Encounter.Success {
+		l.Result = ResultKill
+	} else {
+		l.Result = ResultWipe
+	}
+
+	if len(report.Players) == 0 {
+		return
+	}
+	l.Players = make([]Player, 0, len(report.Players))
+	for _, player := range report.Players {
+		l.Players = append(l.Players, player)
+	}
+	sort.Slice(l.Players, func(i, j int) bool {
+		return l.Players[i].CharacterName < l.Players[j].CharacterName
+	})
+}
+
+// NewUploadContext returns a cancellable context for a new upload of the log.
+func (l *ArcLog) NewUploadContext() context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancelUpload != nil {
+		l.cancelUpload()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancelUpload = cancel
+	return ctx
+}
+
+// CancelUpload cancels the queued or running upload of the log, if there is any.
+func (l *ArcLog) CancelUpload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancelUpload != nil {
+		l.cancelUpload()
+		l.cancelUpload = nil
+	}
 }
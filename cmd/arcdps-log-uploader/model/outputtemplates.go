@@ -0,0 +1,167 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+const outputTemplateDir = "templates"
+
+// EscapeMode determines how text taken from the logs, e.g. the title, is escaped for the chat program.
+type EscapeMode string
+
+const (
+	EscapeNone     EscapeMode = "none"
+	EscapeMarkdown EscapeMode = "markdown"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`,
+)
+
+// Apply escapes the text according to the mode.
+func (m EscapeMode) Apply(text string) string {
+	switch m {
+	case EscapeMarkdown:
+		return markdownEscaper.Replace(text)
+	case EscapeNone:
+		return text
+	}
+	return text
+}
+
+// OutputTemplate defines how the list of uploaded logs is formatted for a chat program.
+// Headline and Line are text/template templates.
+type OutputTemplate struct {
+	Name string `json:"name"`
+	// Headline starts every message.
+	Headline string `json:"headline"`
+	// Line is rendered once per log.
+	Line string `json:"line"`
+	// Separator is put between messages, if the logs do not fit into a single one.
+	Separator string `json:"separator"`
+	// MaxLength is the maximum length of a single message, zero for no limit.
+	MaxLength int        `json:"maxLength"`
+	Escape    EscapeMode `json:"escape"`
+}
+
+const defaultMessageSeparator = "\r\n\r\n--------\r\n\r\n"
+
+// DefaultOutputTemplates are the built-in templates, used unless a template file of the same name overrides them.
+var DefaultOutputTemplates = []OutputTemplate{
+	{
+		Name: "Discord",
+		Headline: "**{{.Title}}{{if and .Title (not .MultipleDays)}} {{end}}" +
+			"{{if not .MultipleDays}}{{if .DynamicTimestamps}}{{timestamp .Date \"d\"}}{{else}}{{date .Date}}{{end}}{{end}}**" +
+			"{{if gt .Pages 1}} ({{.Page}}/{{.Pages}}){{end}}",
+		Line: "{{if .MultipleDays}}{{if .DynamicTimestamps}}{{timestamp .Time \"d\"}}{{else}}`{{date .Time}}`{{end}} {{end}}" +
+			"{{if .DynamicTimestamps}}{{timestamp .Time \"t\"}}{{else}}`{{clock .Time}}`{{end}} " +
+			"{{if .IncludeDuration}}`{{duration .Duration}}` {{end}}<{{.Permalink}}>",
+		Separator: defaultMessageSeparator,
+		MaxLength: 2000,
+		Escape:    EscapeMarkdown,
+	},
+	{
+		Name: "Teamspeak",
+		Headline: "{{.Title}}{{if and .Title (not .MultipleDays)}} {{end}}{{if not .MultipleDays}}{{date .Date}}{{end}}" +
+			"{{if gt .Pages 1}} ({{.Page}}/{{.Pages}}){{end}}",
+		Line: "{{if .MultipleDays}}{{date .Time}} | {{end}}{{clock .Time}} | " +
+			"{{if .IncludeDuration}}{{duration .Duration}} | {{end}}{{.Permalink}}",
+		Separator: defaultMessageSeparator,
+		MaxLength: 10000,
+		Escape:    EscapeNone,
+	},
+}
+
+// LoadOutputTemplates returns the built-in templates, replaced or extended by the template files in the config directory.
+// The built-in templates are written there as examples, as a starting point for own templates.
+func LoadOutputTemplates() []OutputTemplate {
+	templates := append([]OutputTemplate(nil), DefaultOutputTemplates...)
+
+	dir, err := utils.ConfigFile(outputTemplateDir)
+	if err != nil {
+		log.Errorf("Could not load output templates: %v", err)
+		return templates
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		log.Errorf("Could not load output templates: %v", err)
+		return templates
+	}
+	// the examples are not loaded, so they do not hide later changes of the built-in templates
+	writeDefaultOutputTemplates(dir)
+
+	sort.Strings(files)
+	for _, file := range files {
+		outputTemplate, err := readOutputTemplate(file)
+		if err != nil {
+			log.Errorf("Could not load output template %v: %v", filepath.Base(file), err)
+			continue
+		}
+		replaced := false
+		for i := range templates {
+			if strings.EqualFold(templates[i].Name, outputTemplate.Name) {
+				templates[i] = outputTemplate
+				replaced = true
+			}
+		}
+		if !replaced {
+			templates = append(templates, outputTemplate)
+		}
+	}
+	return templates
+}
+
+func readOutputTemplate(file string) (OutputTemplate, error) {
+	var outputTemplate OutputTemplate
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return outputTemplate, err
+	}
+	if err := json.Unmarshal(data, &outputTemplate); err != nil {
+		return outputTemplate, err
+	}
+	if outputTemplate.Name == "" {
+		outputTemplate.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if outputTemplate.Line == "" {
+		return outputTemplate, fmt.Errorf("no line template defined")
+	}
+	if outputTemplate.Escape == "" {
+		outputTemplate.Escape = EscapeNone
+	}
+	return outputTemplate, nil
+}
+
+func writeDefaultOutputTemplates(dir string) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		log.Warnf("Could not create the output template folder: %v", err)
+		return
+	}
+	for _, outputTemplate := range DefaultOutputTemplates {
+		file := filepath.Join(dir, strings.ToLower(outputTemplate.Name)+".json.example")
+		if err := writeOutputTemplate(file, outputTemplate); err != nil {
+			log.Warnf("Could not write output template %v: %v", outputTemplate.Name, err)
+		}
+	}
+}
+
+func writeOutputTemplate(file string, outputTemplate OutputTemplate) error {
+	data := &bytes.Buffer{}
+	encoder := json.NewEncoder(data)
+	// keep the templates readable, e.g. the angle brackets around Discord links
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(outputTemplate); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data.Bytes(), 0o600)
+}
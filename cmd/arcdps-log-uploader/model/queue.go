@@ -0,0 +1,234 @@
+package model
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type QueueOrder int
+
+const (
+	OrderDropped QueueOrder = iota
+	OrderNewestFirst
+	OrderSmallestFirst
+	OrderKillsFirst
+)
+
+type queuedEntry struct {
+	entry   QueueEntry
+	seq     uint64
+	size    int64
+	modTime time.Time
+}
+
+// Queue holds the logs waiting for upload. Entries are handed out in drop order unless a different order is selected.
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	entries []*queuedEntry
+	order   QueueOrder
+	seq     uint64
+	closed  bool
+	paused  bool
+
+	// OnChange is called whenever the positions of the queued entries changed.
+	OnChange func()
+}
+
+func NewQueue() *Queue {
+	q := &Queue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push adds the entry to the queue.
+func (q *Queue) Push(entry QueueEntry) {
+	queued := &queuedEntry{entry: entry}
+	if info, err := os.Stat(entry.ArcLog.File); err == nil {
+		queued.size = info.Size()
+		queued.modTime = info.ModTime()
+	}
+
+	q.mu.Lock()
+	q.seq++
+	queued.seq = q.seq
+	q.entries = append(q.entries, queued)
+	q.sortLocked()
+	paused := q.paused
+	q.mu.Unlock()
+
+	if paused {
+		entry.ArcLog.Status = Paused
+		entry.OnChange()
+	}
+
+	q.cond.Signal()
+	q.changed()
+}
+
+// Pop removes the first entry from the queue, waiting for one if the queue is empty or paused.
+// It returns false once the queue is closed.
+func (q *Queue) Pop() (QueueEntry, bool) {
+	q.mu.Lock()
+	for (len(q.entries) == 0 || q.paused) && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		q.mu.Unlock()
+		return QueueEntry{}, false
+	}
+
+	// the priority of entries may have changed while waiting, e.g. because the result of an encounter became known
+	q.sortLocked()
+	first := q.entries[0]
+	q.entries = q.entries[1:]
+	q.mu.Unlock()
+
+	q.changed()
+	return first.entry, true
+}
+
+// Remove takes the log out of the queue without handing it out. It reports whether the log was queued.
+func (q *Queue) Remove(arcLog *ArcLog) bool {
+	q.mu.Lock()
+	removed := false
+	entries := q.entries[:0]
+	for _, queued := range q.entries {
+		if queued.entry.ArcLog == arcLog {
+			removed = true
+			continue
+		}
+		entries = append(entries, queued)
+	}
+	q.entries = entries
+	q.mu.Unlock()
+
+	if removed {
+		q.changed()
+	}
+	return removed
+}
+
+// Close stops handing out entries and releases all waiting workers.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// Pause stops handing out entries. Uploads which already started are not affected.
+func (q *Queue) Pause() {
+	q.setPaused(true, Paused)
+}
+
+// Resume continues handing out entries after Pause.
+func (q *Queue) Resume() {
+	q.setPaused(false, WaitingInQueue)
+	q.cond.Broadcast()
+}
+
+// Paused reports whether the queue is paused.
+func (q *Queue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+func (q *Queue) setPaused(paused bool, status LogStatus) {
+	q.mu.Lock()
+	q.paused = paused
+	entries := make([]QueueEntry, 0, len(q.entries))
+	for _, queued := range q.entries {
+		// cancelled entries keep their status until a worker drops them
+		if queued.entry.Context.Err() == nil {
+			entries = append(entries, queued.entry)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.ArcLog.Status = status
+		entry.OnChange()
+	}
+}
+
+// SetOrder changes the order in which the queued entries are handed out.
+func (q *Queue) SetOrder(order QueueOrder) {
+	q.mu.Lock()
+	q.order = order
+	q.sortLocked()
+	q.mu.Unlock()
+
+	q.changed()
+}
+
+// Order returns the order in which the queued entries are handed out.
+func (q *Queue) Order() QueueOrder {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.order
+}
+
+// Position returns the 1-based position of the log in the queue, or 0 if it is not queued.
+func (q *Queue) Position(arcLog *ArcLog) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, queued := range q.entries {
+		if queued.entry.ArcLog == arcLog {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Len returns the number of queued entries.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+func (q *Queue) changed() {
+	if q.OnChange != nil {
+		q.OnChange()
+	}
+}
+
+func (q *Queue) sortLocked() {
+	sort.SliceStable(q.entries, func(i, j int) bool {
+		a, b := q.entries[i], q.entries[j]
+		switch q.order {
+		case OrderNewestFirst:
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.After(b.modTime)
+			}
+		case OrderSmallestFirst:
+			if a.size != b.size {
+				return a.size < b.size
+			}
+		case OrderKillsFirst:
+			if ra, rb := killRank(a.entry.ArcLog), killRank(b.entry.ArcLog); ra != rb {
+				return ra < rb
+			}
+		case OrderDropped:
+		}
+		return a.seq < b.seq
+	})
+}
+
+// killRank sorts kills before logs with unknown results, and those before wipes.
+func killRank(arcLog *ArcLog) int {
+	switch arcLog.Result {
+	case ResultKill:
+		return 0
+	case ResultUnknown:
+		return 1
+	case ResultWipe:
+		return 2
+	}
+	return 1
+}
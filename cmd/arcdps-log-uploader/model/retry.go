@@ -0,0 +1,85 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = 60 * time.Second
+)
+
+// statusError is returned for dps.report responses with an unexpected status code.
+type statusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *statusError) Error() string {
+	return "upload failed: " + e.Status
+}
+
+// maxAttemptsFor returns how often an upload may be attempted in total, given the error of the last attempt.
+func maxAttemptsFor(err error, maxRetries int) int {
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		return 1 + uploadErr.Kind.maxRetries(maxRetries)
+	}
+	if isTransient(err) {
+		return 1 + maxRetries
+	}
+	return 1
+}
+
+// isTransient reports whether the error is likely to disappear when the upload is attempted again,
+// like timeouts, reset connections and overloaded servers.
+func isTransient(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		switch status.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// shouldFailover reports whether the upload should be attempted on the next mirror, because the host is overloaded or timed out.
+func shouldFailover(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryDelay returns the exponential backoff delay before the given retry, with jitter applied to spread concurrent retries.
+func retryDelay(retry int) time.Duration {
+	delay := retryBaseDelay << (retry - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1) //nolint:gosec // jitter does not need a secure random source
+}
+
+func attemptsError(err error, attempts int) error {
+	if attempts == 1 {
+		return fmt.Errorf("%w (after 1 attempt)", err)
+	}
+	return fmt.Errorf("%w (after %d attempts)", err, attempts)
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/utils"
+)
+
+const settingsFileName = "settings.json"
+
+// Settings are the user preferences persisted in the config directory.
+type Settings struct {
+	UserToken   string   `json:"userToken"`
+	UploadHost  string   `json:"uploadHost"`
+	MirrorHosts []string `json:"mirrorHosts"`
+	Workers     int      `json:"workers"`
+	WatchFolder string   `json:"watchFolder"`
+	Watch       bool     `json:"watch"`
+
+	FolderRules []FolderRule `json:"folderRules"`
+
+	MinFileSizeKB      int `json:"minFileSizeKb"`
+	MinDurationSeconds int `json:"minDurationSeconds"`
+
+	MaxDetailedWvwSizeMB int `json:"maxDetailedWvwSizeMb"`
+
+	ConvertEvtc      bool `json:"convertEvtc"`
+	KeepOriginalEvtc bool `json:"keepOriginalEvtc"`
+
+	DiscordWebhooks []string `json:"discordWebhooks"`
+
+	TimeFormat TimeFormat `json:"timeFormat"`
+}
+
+func defaultSettings() *Settings {
+	return &Settings{
+		UploadHost:  defaultBaseURL,
+		MirrorHosts: []string{"https://a.dps.report", "https://b.dps.report"},
+		Workers:     5,
+		WatchFolder: DefaultLogFolder(),
+
+		MaxDetailedWvwSizeMB: 10,
+		TimeFormat:           DefaultTimeFormat,
+	}
+}
+
+// LoadSettings reads the persisted settings, using the defaults for everything not stored yet.
+func LoadSettings() *Settings {
+	settings := defaultSettings()
+	if err := utils.LoadJSON(settingsFileName, settings); err != nil {
+		log.Errorf("Could not load settings: %v", err)
+	}
+	return settings
+}
+
+// Hosts returns the upload host followed by the mirrors, in the order they are tried.
+func (s *Settings) Hosts() []string {
+	var hosts []string
+	seen := make(map[string]struct{})
+	for _, host := range append([]string{s.UploadHost}, s.MirrorHosts...) {
+		host = NormalizeHost(host)
+		if _, duplicate := seen[host]; host == "" || duplicate {
+			continue
+		}
+		seen[host] = struct{}{}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, defaultBaseURL)
+	}
+	return hosts
+}
+
+// Apply makes the settings effective for all requests to dps.report and resolves the configured time zone.
+func (s *Settings) Apply() {
+	API.BaseURL = s.Hosts()[0]
+	s.TimeFormat.ResolveLocation()
+}
+
+// NormalizeHost turns user input like "a.dps.report/" into a base URL like "https://a.dps.report".
+func NormalizeHost(host string) string {
+	host = strings.TrimRight(strings.TrimSpace(host), "/")
+	if host != "" && !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return host
+}
+
+// MaxDetailedWvwSize returns the size in bytes up to which WvW logs are uploaded as detailed, zero for no limit.
+func (s *Settings) MaxDetailedWvwSize() int64 {
+	return int64(s.MaxDetailedWvwSizeMB) * 1024 * 1024
+}
+
+// Save persists the settings.
+func (s *Settings) Save() error {
+	return utils.SaveJSON(settingsFileName, s)
+}
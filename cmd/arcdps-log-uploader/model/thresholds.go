@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// SkippedError is returned for logs that are not uploaded, as they are too short to be of interest.
+type SkippedError struct {
+	Reason string
+}
+
+func (e *SkippedError) Error() string {
+	return "skipped: " + e.Reason
+}
+
+// MinFileSize returns the minimum size of a log file in bytes, zero if all logs are uploaded.
+func (s *Settings) MinFileSize() int64 {
+	return int64(s.MinFileSizeKB) * 1024
+}
+
+// MinDuration returns the minimum combat duration of a log, zero if all logs are uploaded.
+func (s *Settings) MinDuration() time.Duration {
+	return time.Duration(s.MinDurationSeconds) * time.Second
+}
+
+// SkipReason returns why the log file should not be uploaded, or an empty string if it should.
+func (s *Settings) SkipReason(path string) string {
+	if reason := ExcludedBy(s.FolderRules, path); reason != "" {
+		return reason
+	}
+	if info, err := os.Stat(path); err == nil {
+		return TooSmall(info.Size(), s.MinFileSize())
+	}
+	return ""
+}
+
+// TooSmall returns why a log file of the given size is skipped, or an empty string if it is large enough.
+func TooSmall(size, minSize int64) string {
+	if minSize <= 0 || size >= minSize {
+		return ""
+	}
+	return fmt.Sprintf("smaller than %d KB", minSize/1024)
+}
+
+// TooShort returns why a log of the given combat duration is skipped, or an empty string if it is long enough.
+func TooShort(duration, minDuration time.Duration) string {
+	if minDuration <= 0 || duration <= 0 || duration >= minDuration {
+		return ""
+	}
+	return fmt.Sprintf("shorter than %v", minDuration)
+}
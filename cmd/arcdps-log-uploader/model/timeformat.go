@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// Windows has no time zone database Go can use, so it is compiled in
+	_ "time/tzdata"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DurationStyle determines how durations are shown.
+type DurationStyle string
+
+const (
+	// DurationUnits shows durations like "04m 05s", or "1h 04m 05s" from one hour on.
+	DurationUnits DurationStyle = "units"
+	// DurationClock shows durations like "04:05", or "1:04:05" from one hour on.
+	DurationClock DurationStyle = "clock"
+)
+
+const LocalTimeZone = "Local"
+
+// TimeFormat determines how dates, times and durations are shown in the table and all outputs.
+type TimeFormat struct {
+	// DateLayout and TimeLayout are Go time layouts, e.g. "2006-01-02" and "15:04".
+	DateLayout    string        `json:"dateLayout"`
+	TimeLayout    string        `json:"timeLayout"`
+	TimeZone      string        `json:"timeZone"`
+	DurationStyle DurationStyle `json:"durationStyle"`
+
+	// location caches the resolved TimeZone, see ResolveLocation.
+	location *time.Location
+}
+
+// DefaultTimeFormat is the format used unless configured otherwise.
+var DefaultTimeFormat = TimeFormat{
+	DateLayout:    "02.01.2006",
+	TimeLayout:    "15:04",
+	TimeZone:      LocalTimeZone,
+	DurationStyle: DurationUnits,
+}
+
+// LoadTimeZone returns the location of an IANA time zone name like "Europe/Berlin", "UTC" or "Local".
+func LoadTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, LocalTimeZone) {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// ResolveLocation loads the configured time zone once, so converting times does not look it up again.
+func (f *TimeFormat) ResolveLocation() {
+	f.location = f.loadLocation()
+}
+
+// Location returns the time zone times are converted to, the local one if the configured zone is unknown.
+func (f TimeFormat) Location() *time.Location {
+	if f.location != nil {
+		return f.location
+	}
+	return f.loadLocation()
+}
+
+func (f TimeFormat) loadLocation() *time.Location {
+	location, err := LoadTimeZone(f.TimeZone)
+	if err != nil {
+		log.Warnf("Unknown time zone %v: %v", f.TimeZone, err)
+		return time.Local
+	}
+	return location
+}
+
+// In converts the time to the configured time zone.
+func (f TimeFormat) In(t time.Time) time.Time {
+	return t.In(f.Location())
+}
+
+// Date formats the date of the time in the configured time zone.
+func (f TimeFormat) Date(t time.Time) string {
+	return f.In(t).Format(orDefault(f.DateLayout, DefaultTimeFormat.DateLayout))
+}
+
+// Time formats the time of day in the configured time zone.
+func (f TimeFormat) Time(t time.Time) string {
+	return f.In(t).Format(orDefault(f.TimeLayout, DefaultTimeFormat.TimeLayout))
+}
+
+// DateTime formats the date and time of day in the configured time zone.
+func (f TimeFormat) DateTime(t time.Time) string {
+	return f.Date(t) + " " + f.Time(t)
+}
+
+// Day returns the start of the day of the time in the configured time zone, to group times by day.
+func (f TimeFormat) Day(t time.Time) time.Time {
+	local := f.In(t)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
+
+// Duration formats the duration, whole seconds only. Durations of an hour or more include the hours.
+func (f TimeFormat) Duration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	seconds := int64(d / time.Second)
+	hours, minutes, seconds := seconds/3600, seconds/60%60, seconds%60
+
+	if f.DurationStyle == DurationClock {
+		if hours > 0 {
+			return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+		}
+		return fmt.Sprintf("%02d:%02d", minutes, seconds)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02dm %02ds", minutes, seconds)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
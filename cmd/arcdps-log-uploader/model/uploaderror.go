@@ -0,0 +1,82 @@
+package model
+
+import (
+	"strings"
+)
+
+type UploadErrorKind int
+
+const (
+	UnknownUploadError UploadErrorKind = iota
+	DuplicateLog
+	UnsupportedLog
+	LogTooShort
+	ParseFailure
+	MissingPermalink
+)
+
+// UploadError is returned when dps.report accepted the upload but could not turn it into a report.
+type UploadError struct {
+	Kind UploadErrorKind
+	// Message is the error as reported by dps.report.
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	switch e.Kind {
+	case DuplicateLog:
+		return "log was already uploaded"
+	case UnsupportedLog:
+		return "log is not supported"
+	case LogTooShort:
+		return "log is too short"
+	case ParseFailure:
+		return "dps.report could not parse the log"
+	case MissingPermalink:
+		return "dps.report did not return a link"
+	case UnknownUploadError:
+		return "dps.report: " + e.Message
+	}
+	return e.Message
+}
+
+// maxRetries returns how often an upload failing with this kind of error is retried.
+// Errors caused by the log itself are final, while problems on the dps.report side are worth another attempt.
+func (k UploadErrorKind) maxRetries(configured int) int {
+	switch k {
+	case DuplicateLog, UnsupportedLog, LogTooShort:
+		return 0
+	case ParseFailure:
+		return min(configured, 1)
+	case MissingPermalink, UnknownUploadError:
+		return configured
+	}
+	return 0
+}
+
+// checkResponse turns a response without a usable report into an UploadError.
+func checkResponse(report *DpsReportResponse) error {
+	if report.Error != "" {
+		return &UploadError{Kind: classifyErrorMessage(report.Error), Message: report.Error}
+	}
+	if report.Permalink == "" {
+		return &UploadError{Kind: MissingPermalink}
+	}
+	return nil
+}
+
+func classifyErrorMessage(message string) UploadErrorKind {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "already") || strings.Contains(lower, "identical") || strings.Contains(lower, "duplicate"):
+		return DuplicateLog
+	case strings.Contains(lower, "too short"):
+		return LogTooShort
+	case strings.Contains(lower, "not supported") || strings.Contains(lower, "unsupported") ||
+		strings.Contains(lower, "not a valid") || strings.Contains(lower, "invalid"):
+		return UnsupportedLog
+	case strings.Contains(lower, "ei failed") || strings.Contains(lower, "parse") || strings.Contains(lower, "parsing"):
+		return ParseFailure
+	}
+	return UnknownUploadError
+}
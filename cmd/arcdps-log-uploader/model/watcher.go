@@ -0,0 +1,123 @@
+package model
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const watchInterval = 3 * time.Second
+
+// Watcher polls a directory tree for new log files. Files are reported once their size stopped changing,
+// so logs still being written by arcdps are not picked up too early.
+// Polling is used instead of OS notifications, as these are unreliable for network drives and hard to test.
+type Watcher struct {
+	dir      string
+	interval time.Duration
+	match    func(path string) bool
+	onFound  func(files []string)
+
+	known   map[string]struct{}
+	pending map[string]int64
+	cancel  context.CancelFunc
+}
+
+// NewWatcher creates a watcher for the directory, which reports new files accepted by match to onFound.
+func NewWatcher(dir string, match func(path string) bool, onFound func(files []string)) *Watcher {
+	return &Watcher{
+		dir:      dir,
+		interval: watchInterval,
+		match:    match,
+		onFound:  onFound,
+		known:    make(map[string]struct{}),
+		pending:  make(map[string]int64),
+	}
+}
+
+// DefaultLogFolder returns the directory arcdps writes its logs to by default.
+func DefaultLogFolder() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "Documents", "Guild Wars 2", "addons", "arcdps", "arcdps.cbtlogs")
+}
+
+// Start begins watching in the background. Files existing at this point are ignored.
+func (w *Watcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	go func() {
+		// the initial scan of years of logs takes a while, so it is not done on the caller's thread
+		w.scan(func(path string, _ fs.DirEntry) {
+			w.known[path] = struct{}{}
+		})
+		log.Infof("Watching %v for new logs", w.dir)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends watching.
+func (w *Watcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	log.Infof("Stopped watching %v", w.dir)
+}
+
+func (w *Watcher) poll() {
+	var found []string
+	w.scan(func(path string, entry fs.DirEntry) {
+		// only new files are looked at closer
+		if _, known := w.known[path]; known {
+			return
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return
+		}
+		size := info.Size()
+		lastSize, seen := w.pending[path]
+		w.pending[path] = size
+		if seen && lastSize == size && size > 0 {
+			delete(w.pending, path)
+			w.known[path] = struct{}{}
+			found = append(found, path)
+		}
+	})
+	if len(found) > 0 {
+		w.onFound(found)
+	}
+}
+
+// scan calls visit for all matching files in the directory tree.
+func (w *Watcher) scan(visit func(path string, entry fs.DirEntry)) {
+	err := filepath.WalkDir(w.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // unreadable entries are skipped, the next poll tries again
+		}
+		if entry.IsDir() || !w.match(path) {
+			return nil
+		}
+		visit(path, entry)
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Could not scan %v: %v", w.dir, err)
+	}
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func isLog(path string) bool {
+	return strings.HasSuffix(path, ".zevtc")
+}
+
+func writeLog(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, filepath.Join(dir, "Vale Guardian", "20240518-201500.zevtc"), 100)
+
+	var found []string
+	w := NewWatcher(dir, isLog, func(files []string) {
+		found = append(found, files...)
+	})
+	w.scan(func(path string, _ fs.DirEntry) {
+		w.known[path] = struct{}{}
+	})
+
+	newLog := filepath.Join(dir, "Gorseval", "20240518-203000.zevtc")
+	writeLog(t, filepath.Join(dir, "Gorseval", "notes.txt"), 10)
+	// empty files are logs arcdps just started to write
+	writeLog(t, filepath.Join(dir, "Sabetha", "20240518-204500.zevtc"), 0)
+
+	steps := []struct {
+		name string
+		size int
+		want bool
+	}{
+		{"first seen", 10, false},
+		{"still growing", 20, false},
+		{"size stable", 20, true},
+		{"already reported", 20, false},
+	}
+	for _, step := range steps {
+		writeLog(t, newLog, step.size)
+		found = nil
+		w.poll()
+		if step.want && (len(found) != 1 || found[0] != newLog) {
+			t.Errorf("%v: found %v, want only the new log", step.name, found)
+		}
+		if !step.want && len(found) > 0 {
+			t.Errorf("%v: found %v, want nothing", step.name, found)
+		}
+	}
+}
+
+func TestWatcherStart(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, filepath.Join(dir, "20240518-201500.zevtc"), 100)
+
+	found := make(chan []string, 10)
+	w := NewWatcher(dir, isLog, func(files []string) {
+		found <- files
+	})
+	w.interval = 10 * time.Millisecond
+	w.Start()
+	defer w.Stop()
+
+	// give the initial scan time to see the existing log
+	time.Sleep(50 * time.Millisecond)
+	newLog := filepath.Join(dir, "20240518-203000.zevtc")
+	writeLog(t, newLog, 100)
+
+	select {
+	case files := <-found:
+		if len(files) != 1 || files[0] != newLog {
+			t.Errorf("found %v, want only the new log", files)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new log was not reported")
+	}
+}
@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lxn/walk"
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
+)
+
+const discordTemplateName = "Discord"
+
+// embedsPageTitle is the title of the output tab showing the Discord embeds.
+const embedsPageTitle = "Discord Embeds"
+
+// discordResult returns the output of the Discord template.
+func discordResult() (Result, bool) {
+	for _, result := range output.Results {
+		if strings.EqualFold(result.Template.Name, discordTemplateName) {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// postToDiscord sends each part of the Discord output, either text or embeds, to the configured webhooks
+// and reports the outcome per part.
+func postToDiscord(mainWindow *walk.MainWindow, postButton *walk.PushButton, embeds bool) {
+	webhooks := settings.DiscordWebhooks
+	if len(webhooks) == 0 {
+		walk.MsgBox(mainWindow, "Post to Discord", "No Discord webhook is configured yet. Please add one in the settings.",
+			walk.MsgBoxOK|walk.MsgBoxIconInformation)
+		return
+	}
+	var messages []model.DiscordMessage
+	if embeds {
+		messages = output.Embeds
+	} else if result, found := discordResult(); found {
+		for _, message := range result.Messages {
+			messages = append(messages, model.DiscordMessage{Content: message})
+		}
+	}
+	if len(messages) == 0 {
+		walk.MsgBox(mainWindow, "Post to Discord", "There is nothing to post yet.",
+			walk.MsgBoxOK|walk.MsgBoxIconInformation)
+		return
+	}
+
+	postButton.SetEnabled(false)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		var report []string
+		failed := false
+		for i, webhook := range webhooks {
+			prefix := ""
+			if len(webhooks) > 1 {
+				prefix = fmt.Sprintf("Webhook %d: ", i+1)
+			}
+			err := model.Discord.PostMessages(ctx, webhook, messages, func(part int, err error) {
+				if err != nil {
+					log.Errorf("Could not post part %d/%d to Discord: %v", part, len(messages), err)
+					report = append(report, fmt.Sprintf("%vPart %d/%d failed: %v", prefix, part, len(messages), err))
+					return
+				}
+				report = append(report, fmt.Sprintf("%vPart %d/%d posted", prefix, part, len(messages)))
+			})
+			if err != nil {
+				failed = true
+			}
+		}
+
+		mainWindow.Synchronize(func() {
+			postButton.SetEnabled(true)
+			icon := walk.MsgBoxIconInformation
+			if failed {
+				icon = walk.MsgBoxIconError
+			}
+			walk.MsgBox(mainWindow, "Post to Discord", strings.Join(report, "\n"), walk.MsgBoxOK|icon)
+		})
+	}()
+}
+
+// formatEmbeds returns the embed messages as JSON, e.g. for bots.
+func formatEmbeds(messages []model.DiscordMessage) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	data := &bytes.Buffer{}
+	encoder := json.NewEncoder(data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(messages); err != nil {
+		log.Errorf("Could not format Discord embeds: %v", err)
+		return ""
+	}
+	// the text box needs windows line breaks
+	return strings.ReplaceAll(data.String(), "\n", "\r\n")
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
+)
+
+const unknownEncounter = "Unknown encounter"
+
+// generateDiscordEmbeds builds Discord messages with one embed per boss, each log being a field of it.
+func generateDiscordEmbeds(entries []*model.ArcLog, formatOptions FormatOptions, timeFormat model.TimeFormat) []model.DiscordMessage {
+	logs, multipleDays := checkedLogs(entries, timeFormat)
+
+	// group by boss, in the order the bosses were first fought
+	var groups [][]ProcessedArcLog
+	groupIndex := make(map[string]int)
+	for _, entry := range logs {
+		key := encounterName(entry.arcLog)
+		index, found := groupIndex[key]
+		if !found {
+			index = len(groups)
+			groupIndex[key] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], entry)
+	}
+
+	var embeds []model.DiscordEmbed
+	for _, group := range groups {
+		for start := 0; start < len(group); start += model.MaxEmbedFields {
+			end := min(start+model.MaxEmbedFields, len(group))
+			embeds = append(embeds, encounterEmbed(group[start:end], multipleDays, formatOptions, timeFormat))
+		}
+	}
+	return packEmbeds(embeds)
+}
+
+func encounterName(arcLog *model.ArcLog) string {
+	name := arcLog.Boss
+	if name == "" {
+		name = unknownEncounter
+	}
+	if arcLog.IsCM {
+		name += " (CM)"
+	}
+	return name
+}
+
+// encounterEmbed builds the embed of logs of the same boss. It is green once the boss was killed, red if all were wipes.
+func encounterEmbed(logs []ProcessedArcLog, multipleDays bool, formatOptions FormatOptions,
+	timeFormat model.TimeFormat) model.DiscordEmbed {
+	title := encounterName(logs[0].arcLog)
+	if trimmedTitle := strings.TrimSpace(formatOptions.Title); trimmedTitle != "" {
+		title = trimmedTitle + " – " + title
+	}
+
+	embed := model.DiscordEmbed{
+		Title:     truncate(title, model.MaxEmbedTitle),
+		Color:     model.DiscordColorUnknown,
+		Timestamp: logs[0].encounterTime.UTC().Format(time.RFC3339),
+	}
+	for _, entry := range logs {
+		switch entry.arcLog.Result {
+		case model.ResultKill:
+			embed.Color = model.DiscordColorKill
+			embed.URL = entry.arcLog.Report.Permalink
+		case model.ResultWipe:
+			if embed.Color == model.DiscordColorUnknown {
+				embed.Color = model.DiscordColorWipe
+			}
+		case model.ResultUnknown:
+		}
+		embed.Fields = append(embed.Fields, logField(entry, multipleDays, formatOptions, timeFormat))
+	}
+	return embed
+}
+
+func logField(entry ProcessedArcLog, multipleDays bool, formatOptions FormatOptions,
+	timeFormat model.TimeFormat) model.DiscordEmbedField {
+	var result string
+	switch entry.arcLog.Result {
+	case model.ResultKill:
+		result = "Kill"
+	case model.ResultWipe:
+		result = "Wipe"
+	case model.ResultUnknown:
+		result = "Log"
+	}
+
+	value := "[dps.report](" + entry.arcLog.Report.Permalink + ")"
+	if duration, found := entry.arcLog.CombatDuration(); found && formatOptions.IncludeDuration {
+		value = "`" + timeFormat.Duration(duration) + "` " + value
+	}
+
+	var name string
+	if formatOptions.DiscordTimestamps {
+		// field names do not render markup, so the timestamp goes into the value
+		name = result
+		timestamp := discordTimestamp(entry.encounterTime, "t")
+		if multipleDays {
+			timestamp = discordTimestamp(entry.encounterTime, "d") + " " + timestamp
+		}
+		value = timestamp + " " + value
+	} else {
+		name = timeFormat.Time(entry.encounterTime) + " · " + result
+		if multipleDays {
+			name = timeFormat.Date(entry.encounterTime) + " " + name
+		}
+	}
+	return model.DiscordEmbedField{
+		Name:   truncate(name, model.MaxEmbedFieldName),
+		Value:  truncate(value, model.MaxEmbedFieldValue),
+		Inline: true,
+	}
+}
+
+// packEmbeds distributes the embeds over as few messages as the limits of Discord allow.
+func packEmbeds(embeds []model.DiscordEmbed) []model.DiscordMessage {
+	var messages []model.DiscordMessage
+	var current model.DiscordMessage
+	currentLength := 0
+	for _, embed := range embeds {
+		length := embed.Length()
+		if len(current.Embeds) > 0 &&
+			(len(current.Embeds) >= model.MaxEmbedsPerMessage || currentLength+length > model.MaxEmbedCharsTotal) {
+			messages = append(messages, current)
+			current = model.DiscordMessage{}
+			currentLength = 0
+		}
+		current.Embeds = append(current.Embeds, embed)
+		currentLength += length
+	}
+	if len(current.Embeds) > 0 {
+		messages = append(messages, current)
+	}
+	return messages
+}
+
+// truncate shortens the text to at most limit characters.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
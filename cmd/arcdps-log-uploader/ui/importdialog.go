@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lxn/walk"
+	"github.com/lxn/walk/declarative"
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
+)
+
+type importRange int
+
+const (
+	importSinceReset importRange = iota
+	importLastHours
+	importDateRange
+)
+
+type importForm struct {
+	Folder string
+	Range  importRange
+	Hours  int
+	From   time.Time
+	To     time.Time
+}
+
+// timeRange returns the time range [from, to) selected in the form.
+func (f *importForm) timeRange(now time.Time) (from, to time.Time) {
+	switch f.Range {
+	case importSinceReset:
+		return model.DailyReset(now), now.Add(time.Hour)
+	case importLastHours:
+		return now.Add(-time.Duration(f.Hours) * time.Hour), now.Add(time.Hour)
+	case importDateRange:
+		from = time.Date(f.From.Year(), f.From.Month(), f.From.Day(), 0, 0, 0, 0, time.Local)
+		to = time.Date(f.To.Year(), f.To.Month(), f.To.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+		return from, to
+	}
+	return now, now
+}
+
+const scanHint = "Scan the folder to see how many logs match."
+
+func runImportDialog(owner walk.Form, onImport func(files []string)) (int, error) {
+	var dlg *walk.Dialog
+	var db *walk.DataBinder
+	var importPB, cancelPB *walk.PushButton
+	var folderEdit *walk.LineEdit
+	var resultLabel *walk.Label
+	var found []string
+	// scans counts the started scans, so the result of a scan for an outdated form is dropped
+	scans := 0
+
+	now := time.Now()
+	form := &importForm{
+		Folder: settings.WatchFolder,
+		Range:  importSinceReset,
+		Hours:  3,
+		From:   now,
+		To:     now,
+	}
+
+	scan := func() {
+		if err := db.Submit(); err != nil {
+			log.Error(err)
+			return
+		}
+		scans++
+		current := scans
+		from, to := form.timeRange(time.Now())
+		folder := form.Folder
+		_ = resultLabel.SetText("Scanning…")
+		importPB.SetEnabled(false)
+
+		go func() {
+			files, err := model.FindLogs(folder, from, to, func(path string) bool {
+				return logFilePattern.MatchString(strings.ToLower(filepath.Base(path)))
+			})
+			dlg.Synchronize(func() {
+				if current != scans {
+					return
+				}
+				if err != nil {
+					_ = resultLabel.SetText("Could not scan folder: " + err.Error())
+					return
+				}
+				found = files
+				_ = resultLabel.SetText(fmt.Sprintf("%d logs found between %v and %v.", len(files),
+					from.Format("2006-01-02 15:04"), to.Format("2006-01-02 15:04")))
+				importPB.SetEnabled(len(files) > 0)
+			})
+		}()
+	}
+
+	// the found logs only match the form they were scanned for
+	onFormChanged := func() {
+		scans++
+		found = nil
+		importPB.SetEnabled(false)
+		_ = resultLabel.SetText(scanHint)
+	}
+
+	return declarative.Dialog{
+		AssignTo:      &dlg,
+		Title:         "Import Logs",
+		DefaultButton: &importPB,
+		CancelButton:  &cancelPB,
+		DataBinder: declarative.DataBinder{
+			AssignTo:       &db,
+			Name:           "import",
+			DataSource:     form,
+			ErrorPresenter: declarative.ToolTipErrorPresenter{},
+			AutoSubmit:     true,
+			OnSubmitted:    onFormChanged,
+		},
+		MinSize: declarative.Size{Width: 450, Height: 250},
+		Layout:  declarative.VBox{},
+		Children: []declarative.Widget{
+			declarative.Composite{
+				Layout: declarative.Grid{Columns: 2},
+				Children: []declarative.Widget{
+					declarative.Label{Text: "Folder"},
+					declarative.Composite{
+						Layout: declarative.HBox{MarginsZero: true},
+						Children: []declarative.Widget{
+							declarative.LineEdit{
+								AssignTo: &folderEdit,
+								Text:     declarative.Bind("Folder"),
+							},
+							declarative.PushButton{
+								Text: "Browse…",
+								OnClicked: func() {
+									if folder, ok := browseFolder(dlg, folderEdit.Text()); ok {
+										_ = folderEdit.SetText(folder)
+									}
+								},
+							},
+						},
+					},
+					declarative.RadioButtonGroupBox{
+						ColumnSpan: 2,
+						Title:      "Time Range",
+						Layout:     declarative.Grid{Columns: 3},
+						DataMember: "Range",
+						Buttons: []declarative.RadioButton{
+							{Text: "Since daily reset", Value: importSinceReset, ColumnSpan: 3},
+							{Text: "Last hours", Value: importLastHours},
+							{Text: "Between", Value: importDateRange, Row: 2},
+						},
+						Children: []declarative.Widget{
+							declarative.NumberEdit{
+								Row:      1,
+								Column:   1,
+								Value:    declarative.Bind("Hours"),
+								MinValue: 1,
+								MaxValue: 24 * 31,
+							},
+							declarative.DateEdit{
+								Row:    2,
+								Column: 1,
+								Date:   declarative.Bind("From"),
+							},
+							declarative.DateEdit{
+								Row:    2,
+								Column: 2,
+								Date:   declarative.Bind("To"),
+							},
+						},
+					},
+				},
+			},
+			declarative.Label{
+				AssignTo: &resultLabel,
+				Text:     scanHint,
+			},
+			declarative.VSpacer{},
+			declarative.Composite{
+				Layout: declarative.HBox{},
+				Children: []declarative.Widget{
+					declarative.PushButton{
+						Text:      "Scan",
+						OnClicked: scan,
+					},
+					declarative.HSpacer{},
+					declarative.PushButton{
+						AssignTo: &importPB,
+						Text:     "Import",
+						Enabled:  false,
+						OnClicked: func() {
+							dlg.Accept()
+							onImport(found)
+						},
+					},
+					declarative.PushButton{
+						AssignTo:  &cancelPB,
+						Text:      "Cancel",
+						OnClicked: func() { dlg.Cancel() },
+					},
+				},
+			},
+		},
+	}.Run(owner)
+}
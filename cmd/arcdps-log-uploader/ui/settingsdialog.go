@@ -0,0 +1,370 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lxn/walk"
+	"github.com/lxn/walk/declarative"
+	log "github.com/sirupsen/logrus"
+	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
+)
+
+// settingsForm holds the settings while they are edited, with lists flattened into one entry per line.
+type settingsForm struct {
+	model.Settings
+	MirrorHostLines string
+	FolderRuleLines string
+	WebhookLines    string
+}
+
+// layoutItem is a choice of a date or time layout, named by an example.
+type layoutItem struct {
+	Layout string
+	Name   string
+}
+
+var dateLayouts = []layoutItem{
+	{"02.01.2006", "31.12.2024"},
+	{"2006-01-02", "2024-12-31 (ISO)"},
+	{"01/02/2006", "12/31/2024"},
+	{"02/01/2006", "31/12/2024"},
+}
+
+var timeLayouts = []layoutItem{
+	{"15:04", "23:59"},
+	{"3:04 PM", "11:59 PM"},
+}
+
+type durationStyleItem struct {
+	Style model.DurationStyle
+	Name  string
+}
+
+var durationStyles = []durationStyleItem{
+	{model.DurationUnits, "1h 04m 05s"},
+	{model.DurationClock, "1:04:05"},
+}
+
+var timeZones = []string{model.LocalTimeZone, "UTC", "Europe/Berlin", "Europe/London", "America/New_York", "America/Los_Angeles"}
+
+const timeZoneHelp = "Time zone of all dates and times, e.g. UTC for the daily reset. Any IANA name like Europe/Paris is accepted."
+
+const folderRulesHelp = "Filter logs by the encounter folder arcdps stored them in. One rule per line, wildcards allowed.\n" +
+	"-WvW* skips all folders starting with WvW, +Qadim* uploads only logs of matching folders."
+
+func runSettingsDialog(owner walk.Form) (int, error) {
+	var dlg *walk.Dialog
+	var db *walk.DataBinder
+	var acceptPB, cancelPB *walk.PushButton
+	var tokenEdit *walk.LineEdit
+	var watchFolderEdit *walk.LineEdit
+
+	edited := settingsForm{
+		Settings:        *settings,
+		MirrorHostLines: strings.Join(settings.MirrorHosts, "\r\n"),
+		FolderRuleLines: formatFolderRules(settings.FolderRules),
+		WebhookLines:    strings.Join(settings.DiscordWebhooks, "\r\n"),
+	}
+
+	return declarative.Dialog{
+		AssignTo:      &dlg,
+		Title:         "Settings",
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		DataBinder: declarative.DataBinder{
+			AssignTo:       &db,
+			Name:           "settings",
+			DataSource:     &edited,
+			ErrorPresenter: declarative.ToolTipErrorPresenter{},
+		},
+		MinSize: declarative.Size{Width: 450, Height: 150},
+		Layout:  declarative.VBox{},
+		Children: []declarative.Widget{
+			declarative.Composite{
+				Layout: declarative.Grid{Columns: 2},
+				Children: []declarative.Widget{
+					declarative.Label{
+						Text:        "dps.report User Token",
+						ToolTipText: "Uploads are associated with this token, so they can be found on dps.report later.",
+					},
+					declarative.Composite{
+						Layout: declarative.HBox{MarginsZero: true},
+						Children: []declarative.Widget{
+							declarative.LineEdit{
+								AssignTo:    &tokenEdit,
+								Text:        declarative.Bind("UserToken"),
+								ToolTipText: "Paste an existing token to upload into the same account from multiple computers.",
+							},
+							declarative.PushButton{
+								Text: "Request new",
+								OnClicked: func() {
+									token, err := requestUserToken()
+									if err != nil {
+										walk.MsgBox(dlg, "Error", "Could not request a new user token:\n"+err.Error(),
+											walk.MsgBoxOK|walk.MsgBoxIconError)
+										return
+									}
+									_ = tokenEdit.SetText(token)
+								},
+							},
+						},
+					},
+					declarative.Label{
+						Text:        "Upload Host",
+						ToolTipText: "dps.report host used for uploads, e.g. a local caching proxy.",
+					},
+					declarative.LineEdit{
+						Text:        declarative.Bind("UploadHost"),
+						ToolTipText: "dps.report host used for uploads, e.g. a local caching proxy.",
+					},
+					declarative.Label{
+						Text:        "Mirror Hosts",
+						ToolTipText: "Hosts tried in order when the upload host is overloaded or does not respond. One host per line.",
+					},
+					declarative.TextEdit{
+						Text:        declarative.Bind("MirrorHostLines"),
+						ToolTipText: "Hosts tried in order when the upload host is overloaded or does not respond. One host per line.",
+						MinSize:     declarative.Size{Height: 50},
+					},
+					declarative.Label{
+						Text:        "Log Folder",
+						ToolTipText: "Folder watched for new logs, usually arcdps.cbtlogs.",
+					},
+					declarative.Composite{
+						Layout: declarative.HBox{MarginsZero: true},
+						Children: []declarative.Widget{
+							declarative.LineEdit{
+								AssignTo:    &watchFolderEdit,
+								Text:        declarative.Bind("WatchFolder"),
+								ToolTipText: "Folder watched for new logs, usually arcdps.cbtlogs.",
+							},
+							declarative.PushButton{
+								Text: "Browse…",
+								OnClicked: func() {
+									if folder, ok := browseFolder(dlg, watchFolderEdit.Text()); ok {
+										_ = watchFolderEdit.SetText(folder)
+									}
+								},
+							},
+						},
+					},
+					declarative.Label{
+						Text:        "Folder Rules",
+						ToolTipText: folderRulesHelp,
+					},
+					declarative.TextEdit{
+						Text:        declarative.Bind("FolderRuleLines"),
+						ToolTipText: folderRulesHelp,
+						MinSize:     declarative.Size{Height: 60},
+					},
+					declarative.Label{
+						Text:        "Parallel Uploads",
+						ToolTipText: "Number of logs uploaded at the same time. Takes effect after a restart.",
+					},
+					declarative.NumberEdit{
+						Value:       declarative.Bind("Workers"),
+						ToolTipText: "Number of logs uploaded at the same time. Takes effect after a restart.",
+						MinValue:    1,
+						MaxValue:    10,
+					},
+					declarative.Label{
+						Text:        "Min. File Size (KB)",
+						ToolTipText: "Smaller logs, e.g. aborted pulls, are skipped when they are added. 0 uploads all logs.",
+					},
+					declarative.NumberEdit{
+						Value:       declarative.Bind("MinFileSizeKB"),
+						ToolTipText: "Smaller logs, e.g. aborted pulls, are skipped when they are added. 0 uploads all logs.",
+						MinValue:    0,
+						MaxValue:    100000,
+					},
+					declarative.Label{
+						Text:        "Min. Combat Duration (s)",
+						ToolTipText: "Shorter logs are skipped, or not selected if their duration is only known after the upload. 0 uploads all logs.",
+					},
+					declarative.NumberEdit{
+						Value:       declarative.Bind("MinDurationSeconds"),
+						ToolTipText: "Shorter logs are skipped, or not selected if their duration is only known after the upload. 0 uploads all logs.",
+						MinValue:    0,
+						MaxValue:    3600,
+					},
+					declarative.Label{
+						Text:        "Max. Detailed WvW Size (MB)",
+						ToolTipText: "Larger WvW logs are uploaded as non-detailed, as dps.report cannot process them. 0 for no limit.",
+					},
+					declarative.NumberEdit{
+						Value:       declarative.Bind("MaxDetailedWvwSizeMB"),
+						ToolTipText: "Larger WvW logs are uploaded as non-detailed, as dps.report cannot process them. 0 for no limit.",
+						MinValue:    0,
+						MaxValue:    1000,
+					},
+					declarative.Label{
+						Text:        "Compress .evtc Logs",
+						ToolTipText: "Replace raw .evtc logs by the much smaller .zevtc format on disk before they are uploaded.",
+					},
+					declarative.CheckBox{
+						Name:        "convertEvtcCB",
+						Checked:     declarative.Bind("ConvertEvtc"),
+						ToolTipText: "Replace raw .evtc logs by the much smaller .zevtc format on disk before they are uploaded.",
+					},
+					declarative.Label{
+						Text:        "Keep Original .evtc",
+						ToolTipText: "Keep the raw log next to the compressed one instead of deleting it.",
+					},
+					declarative.CheckBox{
+						Checked:     declarative.Bind("KeepOriginalEvtc"),
+						Enabled:     declarative.Bind("convertEvtcCB.Checked"),
+						ToolTipText: "Keep the raw log next to the compressed one instead of deleting it.",
+					},
+					declarative.Label{
+						Text:        "Date Format",
+						ToolTipText: "Format of dates in the table and all outputs.",
+					},
+					declarative.ComboBox{
+						Value:         declarative.Bind("TimeFormat.DateLayout"),
+						BindingMember: "Layout",
+						DisplayMember: "Name",
+						Model:         dateLayouts,
+						ToolTipText:   "Format of dates in the table and all outputs.",
+					},
+					declarative.Label{
+						Text:        "Time Format",
+						ToolTipText: "Format of times in the table and all outputs.",
+					},
+					declarative.ComboBox{
+						Value:         declarative.Bind("TimeFormat.TimeLayout"),
+						BindingMember: "Layout",
+						DisplayMember: "Name",
+						Model:         timeLayouts,
+						ToolTipText:   "Format of times in the table and all outputs.",
+					},
+					declarative.Label{
+						Text:        "Time Zone",
+						ToolTipText: timeZoneHelp,
+					},
+					declarative.ComboBox{
+						Editable:    true,
+						Value:       declarative.Bind("TimeFormat.TimeZone"),
+						Model:       timeZones,
+						ToolTipText: timeZoneHelp,
+					},
+					declarative.Label{
+						Text:        "Duration Format",
+						ToolTipText: "Format of combat times in the table and all outputs.",
+					},
+					declarative.ComboBox{
+						Value:         declarative.Bind("TimeFormat.DurationStyle"),
+						BindingMember: "Style",
+						DisplayMember: "Name",
+						Model:         durationStyles,
+						ToolTipText:   "Format of combat times in the table and all outputs.",
+					},
+					declarative.Label{
+						Text:        "Discord Webhooks",
+						ToolTipText: "Webhook URLs the output is posted to with \"Post to Discord\". One URL per line.",
+					},
+					declarative.TextEdit{
+						Text:        declarative.Bind("WebhookLines"),
+						ToolTipText: "Webhook URLs the output is posted to with \"Post to Discord\". One URL per line.",
+						MinSize:     declarative.Size{Height: 40},
+					},
+				},
+			},
+			declarative.VSpacer{},
+			declarative.Composite{
+				Layout: declarative.HBox{},
+				Children: []declarative.Widget{
+					declarative.HSpacer{},
+					declarative.PushButton{
+						AssignTo: &acceptPB,
+						Text:     "OK",
+						OnClicked: func() {
+							if err := db.Submit(); err != nil {
+								log.Error(err)
+								return
+							}
+							edited.MirrorHosts = splitLines(edited.MirrorHostLines)
+							edited.FolderRules = model.ParseFolderRules(splitLines(edited.FolderRuleLines))
+							edited.DiscordWebhooks = splitLines(edited.WebhookLines)
+							if _, err := model.LoadTimeZone(edited.TimeFormat.TimeZone); err != nil {
+								walk.MsgBox(dlg, "Settings", "Unknown time zone "+edited.TimeFormat.TimeZone,
+									walk.MsgBoxOK|walk.MsgBoxIconWarning)
+								return
+							}
+							*settings = edited.Settings
+							settings.Apply()
+							if err := settings.Save(); err != nil {
+								log.Errorf("Could not save settings: %v", err)
+							}
+							dlg.Accept()
+						},
+					},
+					declarative.PushButton{
+						AssignTo:  &cancelPB,
+						Text:      "Cancel",
+						OnClicked: func() { dlg.Cancel() },
+					},
+				},
+			},
+		},
+	}.Run(owner)
+}
+
+func requestUserToken() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return model.API.GetUserToken(ctx)
+}
+
+// ensureUserToken requests a user token on first start, so uploads are never orphaned.
+// The token is stored on the UI thread, unless one was entered in the meantime.
+func ensureUserToken(form walk.Form) {
+	token, err := requestUserToken()
+	if err != nil {
+		log.Errorf("Could not request a dps.report user token: %v", err)
+		return
+	}
+	form.Synchronize(func() {
+		if settings.UserToken != "" {
+			return
+		}
+		settings.UserToken = token
+		if err := settings.Save(); err != nil {
+			log.Errorf("Could not save settings: %v", err)
+		}
+	})
+}
+
+func browseFolder(owner walk.Form, initialDir string) (string, bool) {
+	dlg := &walk.FileDialog{
+		Title:          "Select log folder",
+		InitialDirPath: initialDir,
+	}
+	ok, err := dlg.ShowBrowseFolder(owner)
+	if err != nil {
+		log.Error(err)
+		return "", false
+	}
+	return dlg.FilePath, ok
+}
+
+func formatFolderRules(rules []model.FolderRule) string {
+	lines := make([]string, len(rules))
+	for i, rule := range rules {
+		lines[i] = rule.String()
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// splitLines returns the trimmed, non-empty lines of the text.
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
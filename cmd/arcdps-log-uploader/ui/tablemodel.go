@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
-	"time"
+	"strings"
 
 	"github.com/lxn/walk"
 	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
@@ -35,18 +35,44 @@ func (m *ArcLogModel) Sort(col int, order walk.SortOrder) error {
 			return a.Status < b.Status
 		},
 		func(a, b *model.ArcLog) bool {
-			comparisonResult, oneIsMissing := modelUnavailable(a, b)
-			if oneIsMissing {
-				return comparisonResult
+			aTime, aFound := a.EncounterTime()
+			bTime, bFound := b.EncounterTime()
+			if !aFound || !bFound {
+				return aFound
 			}
-			return time.Time(a.Report.EncounterTime).Before(time.Time(b.Report.EncounterTime))
+			return aTime.Before(bTime)
 		},
 		func(a, b *model.ArcLog) bool {
-			comparisonResult, oneIsMissing := modelUnavailable(a, b)
-			if oneIsMissing {
-				return comparisonResult
+			aDuration, aFound := a.CombatDuration()
+			bDuration, bFound := b.CombatDuration()
+			if !aFound || !bFound {
+				return aFound
 			}
-			return a.Report.Encounter.Duration < b.Report.Encounter.Duration
+			return aDuration < bDuration
+		},
+		func(a, b *model.ArcLog) bool {
+			return a.Boss < b.Boss
+		},
+		func(a, b *model.ArcLog) bool {
+			return a.Result < b.Result
+		},
+		func(a, b *model.ArcLog) bool {
+			return !a.IsCM && b.IsCM
+		},
+		func(a, b *model.ArcLog) bool {
+			return len(a.Players) < len(b.Players)
+		},
+		func(a, b *model.ArcLog) bool {
+			return a.RecordedBy < b.RecordedBy
+		},
+		func(a, b *model.ArcLog) bool {
+			return squadDps(a) < squadDps(b)
+		},
+		func(a, b *model.ArcLog) bool {
+			return bossHealthLeft(a) < bossHealthLeft(b)
+		},
+		func(a, b *model.ArcLog) bool {
+			return a.UploadHost < b.UploadHost
 		},
 		func(a, b *model.ArcLog) bool {
 			return a.Detailed < b.Detailed
@@ -94,6 +120,25 @@ func modelUnavailable(a, b *model.ArcLog) (result, oneOrMoreIsMissing bool) {
 	return false, false
 }
 
+func squadDps(item *model.ArcLog) int {
+	if item.EliteInsights == nil {
+		return -1
+	}
+	return item.EliteInsights.SquadDps()
+}
+
+func bossHealthLeft(item *model.ArcLog) float64 {
+	if item.EliteInsights != nil {
+		if healthLeft, found := item.EliteInsights.BossHealthLeft(); found {
+			return healthLeft
+		}
+	}
+	if item.Encounter != nil && item.Encounter.BossHealthKnown {
+		return item.Encounter.BossHealth
+	}
+	return -1
+}
+
 func (m *ArcLogModel) RefreshTable() {
 	// Notify TableView and other interested parties about the reset.
 	m.PublishRowsReset()
@@ -111,6 +156,9 @@ func (m *ArcLogModel) Value(row, col int) interface{} {
 
 	valueFunc := []func(item *model.ArcLog) interface{}{
 		func(item *model.ArcLog) interface{} {
+			if item.File == "" && item.Report != nil {
+				return item.Report.ID
+			}
 			return filepath.Base(item.File)
 		},
 		func(item *model.ArcLog) interface{} {
@@ -118,33 +166,95 @@ func (m *ArcLogModel) Value(row, col int) interface{} {
 			case model.Outstanding:
 				return "Outstanding"
 			case model.WaitingInQueue:
+				if position := model.UploadQueue.Position(item); position > 0 {
+					return fmt.Sprintf("Waiting (Queue #%d)", position)
+				}
 				return "Waiting (Queue)"
+			case model.Paused:
+				if position := model.UploadQueue.Position(item); position > 0 {
+					return fmt.Sprintf("Paused (Queue #%d)", position)
+				}
+				return "Paused"
 			case model.WaitingRateLimitingHard:
 				return "Waiting (Rate Limited)"
 			case model.WaitingRateLimiting:
 				return "Waiting (Rate Limit)"
 			case model.Uploading:
-				return "Uploading"
+				return fmt.Sprintf("Uploading (%d%%)", item.UploadProgress)
+			case model.WaitingRetry:
+				return "Waiting (Retry)"
 			case model.Done:
+				if item.FromHistory {
+					return "Done (History)"
+				}
 				return "Done"
 			case model.Error:
 				return fmt.Sprintf("Error (%v)", item.ErrorMessage)
+			case model.Cancelled:
+				return "Cancelled"
+			case model.Skipped:
+				return fmt.Sprintf("Skipped (%v)", item.SkipReason)
 			}
 			return "Unknown"
 		},
 		func(item *model.ArcLog) interface{} {
-			if item.Report != nil {
-				return time.Time(item.Report.EncounterTime)
+			if encounterTime, found := item.EncounterTime(); found {
+				return settings.TimeFormat.DateTime(encounterTime)
 			}
 			return ""
 		},
 		func(item *model.ArcLog) interface{} {
-			if item.Report != nil {
-				out := time.Time{}.Add(time.Duration(item.Report.Encounter.Duration) * time.Second)
-				return out.Format("04m 05s")
+			if duration, found := item.CombatDuration(); found {
+				return settings.TimeFormat.Duration(duration)
+			}
+			return ""
+		},
+		func(item *model.ArcLog) interface{} {
+			return item.Boss
+		},
+		func(item *model.ArcLog) interface{} {
+			switch item.Result {
+			case model.ResultKill:
+				return "Kill"
+			case model.ResultWipe:
+				return "Wipe"
+			case model.ResultUnknown:
+				return ""
+			}
+			return ""
+		},
+		func(item *model.ArcLog) interface{} {
+			if item.IsCM {
+				return checkmark
+			}
+			return ""
+		},
+		func(item *model.ArcLog) interface{} {
+			if len(item.Players) > 0 {
+				return len(item.Players)
 			}
 			return ""
 		},
+		func(item *model.ArcLog) interface{} {
+			return item.RecordedBy
+		},
+		func(item *model.ArcLog) interface{} {
+			if item.EliteInsights != nil {
+				return squadDps(item)
+			}
+			return ""
+		},
+		func(item *model.ArcLog) interface{} {
+			if item.Result != model.ResultKill {
+				if healthLeft := bossHealthLeft(item); healthLeft >= 0 {
+					return fmt.Sprintf("%.1f%%", healthLeft)
+				}
+			}
+			return ""
+		},
+		func(item *model.ArcLog) interface{} {
+			return strings.TrimPrefix(item.UploadHost, "https://")
+		},
 		func(item *model.ArcLog) interface{} {
 			switch item.Detailed {
 			case model.True:
@@ -200,6 +310,15 @@ func (m *ArcLogModel) IndexOf(item *model.ArcLog) int {
 	return -1
 }
 
+func reportAlreadyInList(m *ArcLogModel, permalink string) bool {
+	for _, item := range m.items {
+		if item.Report != nil && item.Report.Permalink == permalink {
+			return true
+		}
+	}
+	return false
+}
+
 func fileAlreadyInList(m *ArcLogModel, file string) (int, *model.ArcLog) {
 	for i, item := range m.items {
 		if item.File == file {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/xyaren/arcdps-log-uploader/cmd/arcdps-log-uploader/model"
@@ -14,191 +15,191 @@ type ProcessedArcLog struct {
 	encounterTime time.Time
 }
 
-func generateMessageText(entries []*model.ArcLog, formatOptions FormatOptions) Results {
-	return Results{
-		Discord:   generateMessageTextDiscord(entries, formatOptions),
-		Teamspeak: generateMessageTextTeamspeak(entries, formatOptions),
-	}
+// Result is the output of one template, split into messages that fit into its length limit.
+type Result struct {
+	Template model.OutputTemplate
+	Messages []string
 }
 
-func generateMessageTextDiscord(entries []*model.ArcLog, formatOptions FormatOptions) string {
-	var dates = make(map[time.Time]struct{}) // make a "set"
-	var result []ProcessedArcLog
-	for _, arcLog := range entries {
-		if arcLog.Report != nil {
-			if arcLog.Checked {
-				encounterTime := time.Time(arcLog.Report.EncounterTime)
-				arcLog := ProcessedArcLog{arcLog, encounterTime}
-				result = append(result, arcLog)
-				dateOnly := time.Date(encounterTime.Year(), encounterTime.Month(), encounterTime.Day(), 0, 0, 0, 0, time.Local)
-				dates[dateOnly] = struct{}{}
-			}
-		}
-	}
-
-	if len(result) < 1 {
-		return ""
-	}
-
-	sort.SliceStable(result, func(i, j int) bool {
-		return result[i].encounterTime.Before(result[j].encounterTime)
-	})
-
-	var lines []string
+// Text returns all messages joined by the separator of the template.
+func (r Result) Text() string {
+	return strings.Join(r.Messages, r.Template.Separator)
+}
 
-	for _, entry := range result {
-		output := ""
-		const tick = "`"
-		const space = " "
-		if len(dates) > 1 {
-			output += tick + entry.encounterTime.Format("02.01.2006") + tick
-			output += space
-		}
-		output += tick + entry.encounterTime.Format("15:04") + tick
-		output += space
+// headlineData is passed to the headline template.
+type headlineData struct {
+	Title        string
+	Date         time.Time
+	MultipleDays bool
+	Page         int
+	Pages        int
+	// DynamicTimestamps requests Discord timestamps, which show the time in the time zone of each reader.
+	DynamicTimestamps bool
+}
 
-		if formatOptions.IncludeDuration {
-			out := time.Time{}.Add(time.Duration(entry.arcLog.Report.Encounter.Duration) * time.Second)
-			output += tick + out.Format("04m 05s") + tick
-			output += space
-		}
+// lineData is passed to the line template, once per log.
+type lineData struct {
+	Time            time.Time
+	Duration        time.Duration
+	IncludeDuration bool
+	MultipleDays    bool
+	Permalink       string
+	Boss            string
+	Kill            bool
+	CM              bool
+	// SquadDps is the summed up dps of all players from the Elite Insights data, -1 if it was not fetched.
+	SquadDps int
+	// BossHealthLeft is the remaining health of the boss in percent, -1 if it is not known.
+	BossHealthLeft float64
+	// DynamicTimestamps requests Discord timestamps, which show the time in the time zone of each reader.
+	DynamicTimestamps bool
+}
 
-		output += "<"
-		output += entry.arcLog.Report.Permalink
-		output += ">"
-		lines = append(lines, output)
+// templateFuncs returns the functions available in templates, formatting as configured in the settings.
+func templateFuncs(timeFormat model.TimeFormat) template.FuncMap {
+	return template.FuncMap{
+		"date":      timeFormat.Date,
+		"clock":     timeFormat.Time,
+		"duration":  timeFormat.Duration,
+		"timestamp": discordTimestamp,
 	}
+}
 
-	firstDateTime := result[0].encounterTime
-	headline := headline(formatOptions.Title, firstDateTime, len(dates) > 1)
+// discordTimestamp returns the Discord markup for the time in the given style, e.g. "t" for the time or "d" for the date.
+func discordTimestamp(t time.Time, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), style)
+}
 
-	var messages []string
-	var currentMessage = ""
-	for _, line := range lines {
-		const linebreak = "\r\n"
-		var currentMessagePlusThisLine = currentMessage + linebreak + line
-		if len(currentMessagePlusThisLine) > (2000 - len(headline) - 10) {
-			messages = append(messages, currentMessage)
-			currentMessage = linebreak + line
-		} else {
-			currentMessage = currentMessagePlusThisLine
-		}
-	}
-	if currentMessage != "" {
-		messages = append(messages, currentMessage)
-	}
+func generateMessageText(entries []*model.ArcLog, formatOptions FormatOptions, templates []model.OutputTemplate,
+	timeFormat model.TimeFormat) []Result {
+	result, multipleDays := checkedLogs(entries, timeFormat)
 
-	for i, message := range messages {
-		paging := ""
-		if len(messages) > 1 {
-			paging = fmt.Sprintf(" (%d/%d)", i+1, len(messages))
+	results := make([]Result, len(templates))
+	for i, outputTemplate := range templates {
+		results[i].Template = outputTemplate
+		if len(result) < 1 {
+			continue
 		}
-		messages[i] = headline + paging + message
+		messages, err := generateMessages(result, multipleDays, formatOptions, outputTemplate, timeFormat)
+		if err != nil {
+			messages = []string{fmt.Sprintf("Template %v is invalid: %v", outputTemplate.Name, err)}
+		}
+		results[i].Messages = messages
 	}
-
-	return strings.Join(messages, "\r\n\r\n--------\r\n\r\n")
+	return results
 }
 
-func generateMessageTextTeamspeak(entries []*model.ArcLog, formatOptions FormatOptions) string {
+// checkedLogs returns the selected, uploaded logs in the order they were recorded
+// and whether they were recorded on more than one day, both in the configured time zone.
+func checkedLogs(entries []*model.ArcLog, timeFormat model.TimeFormat) ([]ProcessedArcLog, bool) {
 	var dates = make(map[time.Time]struct{}) // make a "set"
 	var result []ProcessedArcLog
 	for _, arcLog := range entries {
 		if arcLog.Report != nil {
 			if arcLog.Checked {
-				encounterTime := time.Time(arcLog.Report.EncounterTime)
+				encounterTime := timeFormat.In(time.Time(arcLog.Report.EncounterTime))
 				arcLog := ProcessedArcLog{arcLog, encounterTime}
 				result = append(result, arcLog)
-				dateOnly := time.Date(encounterTime.Year(), encounterTime.Month(), encounterTime.Day(), 0, 0, 0, 0, time.Local)
-				dates[dateOnly] = struct{}{}
+				dates[timeFormat.Day(encounterTime)] = struct{}{}
 			}
 		}
 	}
 
-	if len(result) < 1 {
-		return ""
-	}
-
 	sort.SliceStable(result, func(i, j int) bool {
 		return result[i].encounterTime.Before(result[j].encounterTime)
 	})
+	return result, len(dates) > 1
+}
 
-	var lines []string
+func generateMessages(entries []ProcessedArcLog, multipleDays bool, formatOptions FormatOptions,
+	outputTemplate model.OutputTemplate, timeFormat model.TimeFormat) ([]string, error) {
+	funcs := templateFuncs(timeFormat)
+	headlineTemplate, err := template.New("headline").Funcs(funcs).Parse(outputTemplate.Headline)
+	if err != nil {
+		return nil, err
+	}
+	lineTemplate, err := template.New("line").Funcs(funcs).Parse(outputTemplate.Line)
+	if err != nil {
+		return nil, err
+	}
+	escape := outputTemplate.Escape.Apply
 
-	const separator = " | "
-	for _, entry := range result {
-		output := ""
-		if len(dates) > 1 {
-			output += entry.encounterTime.Format("02.01.2006")
-			output += separator
+	var lines []string
+	for _, entry := range entries {
+		duration, _ := entry.arcLog.CombatDuration()
+		line, err := render(lineTemplate, lineData{
+			Time:            entry.encounterTime,
+			Duration:        duration,
+			IncludeDuration: formatOptions.IncludeDuration,
+			MultipleDays:    multipleDays,
+			Permalink:       entry.arcLog.Report.Permalink,
+			Boss:            escape(entry.arcLog.Boss),
+			Kill:            entry.arcLog.Result == model.ResultKill,
+			CM:              entry.arcLog.IsCM,
+			SquadDps:        squadDps(entry.arcLog),
+			BossHealthLeft:  bossHealthLeft(entry.arcLog),
+
+			DynamicTimestamps: formatOptions.DiscordTimestamps,
+		})
+		if err != nil {
+			return nil, err
 		}
-		output += entry.encounterTime.Format("15:04")
-		output += separator
+		lines = append(lines, line)
+	}
 
-		if formatOptions.IncludeDuration {
-			out := time.Time{}.Add(time.Duration(entry.arcLog.Report.Encounter.Duration) * time.Second)
-			output += out.Format("04m 05s")
-			output += separator
-		}
-		output += entry.arcLog.Report.Permalink
+	headline := func(page, pages int) (string, error) {
+		return render(headlineTemplate, headlineData{
+			Title:        escape(strings.TrimSpace(formatOptions.Title)),
+			Date:         entries[0].encounterTime,
+			MultipleDays: multipleDays,
+			Page:         page,
+			Pages:        pages,
 
-		lines = append(lines, output)
+			DynamicTimestamps: formatOptions.DiscordTimestamps,
+		})
 	}
 
-	firstDateTime := result[0].encounterTime
-	headline := headlineTeamspeak(formatOptions.Title, firstDateTime, len(dates) > 1)
-
-	var messages []string
-	var currentMessage = ""
+	// the longest possible headline is reserved in every message, as the number of pages is not known yet.
+	// Lengths are measured on the rendered text, so timestamp markup is accounted for.
+	longestHeadline, err := headline(len(lines), len(lines))
+	if err != nil {
+		return nil, err
+	}
+	const linebreak = "\r\n"
+	var pages [][]string
+	var currentPage []string
+	currentLength := len(longestHeadline)
 	for _, line := range lines {
-		const linebreak = "\r\n"
-		var currentMessagePlusThisLine = currentMessage + linebreak + line
-		if len(currentMessagePlusThisLine) > (10000 - len(headline) - 10) {
-			messages = append(messages, currentMessage)
-			currentMessage = linebreak + line
-		} else {
-			currentMessage = currentMessagePlusThisLine
+		lineLength := len(linebreak) + len(line)
+		if outputTemplate.MaxLength > 0 && len(currentPage) > 0 && currentLength+lineLength > outputTemplate.MaxLength {
+			pages = append(pages, currentPage)
+			currentPage = nil
+			currentLength = len(longestHeadline)
 		}
+		currentPage = append(currentPage, line)
+		currentLength += lineLength
 	}
-	if currentMessage != "" {
-		messages = append(messages, currentMessage)
-	}
+	pages = append(pages, currentPage)
 
-	for i, message := range messages {
-		paging := ""
-		if len(messages) > 1 {
-			paging = fmt.Sprintf(" (%d/%d)", i+1, len(messages))
+	messages := make([]string, len(pages))
+	for i, page := range pages {
+		pageHeadline, err := headline(i+1, len(pages))
+		if err != nil {
+			return nil, err
+		}
+		if pageHeadline == "" {
+			messages[i] = strings.Join(page, linebreak)
+		} else {
+			messages[i] = pageHeadline + linebreak + strings.Join(page, linebreak)
 		}
-		messages[i] = headline + paging + message
-	}
-
-	return strings.Join(messages, "\r\n\r\n--------\r\n\r\n")
-}
-
-func headline(title string, dateTime time.Time, multipleDays bool) string {
-	var elements = make([]string, 0)
-	trimmedTitle := strings.TrimSpace(title)
-	if trimmedTitle != "" {
-		elements = append(elements, trimmedTitle)
-	}
-
-	if !multipleDays {
-		elements = append(elements, dateTime.Format("02.01.2006"))
 	}
-
-	const bold = "**"
-	return bold + strings.Join(elements, " ") + bold
+	return messages, nil
 }
 
-func headlineTeamspeak(title string, dateTime time.Time, multipleDays bool) string {
-	var elements = make([]string, 0)
-	trimmedTitle := strings.TrimSpace(title)
-	if trimmedTitle != "" {
-		elements = append(elements, trimmedTitle)
+func render(t *template.Template, data interface{}) (string, error) {
+	var text strings.Builder
+	if err := t.Execute(&text, data); err != nil {
+		return "", err
 	}
-
-	if !multipleDays {
-		elements = append(elements, dateTime.Format("02.01.2006"))
-	}
-
-	return strings.Join(elements, " ")
+	return text.String(), nil
 }
@@ -3,6 +3,7 @@ package ui
 //goland:noinspection GoLinterLocal
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -33,33 +34,52 @@ var changeCallback func(arcLog *model.ArcLog, linkChanged bool)
 var latestVersion *selfupdate.Release
 
 type Options struct {
-	DetailedWvw bool
-	Anonymous   bool
+	DetailedWvw        bool
+	Anonymous          bool
+	MaxRetries         int
+	FetchEliteInsights bool
+	DeselectWipes      bool
+	QueueOrder         model.QueueOrder
+	WatchFolder        bool
+}
+
+type queueOrderItem struct {
+	Order model.QueueOrder
+	Name  string
+}
+
+var queueOrders = []*queueOrderItem{
+	{model.OrderDropped, "Drop order"},
+	{model.OrderNewestFirst, "Newest first"},
+	{model.OrderSmallestFirst, "Smallest first"},
+	{model.OrderKillsFirst, "Kills first"},
 }
 
 type Output struct {
 	FormatOptions FormatOptions
-	Results       Results
+	Results       []Result
+	Embeds        []model.DiscordMessage
 }
 type FormatOptions struct {
-	Title           string
-	IncludeDuration bool
-}
-type Results struct {
-	Discord   string
-	Teamspeak string
+	Title             string
+	IncludeDuration   bool
+	DiscordTimestamps bool
 }
 
 var options = new(Options)
 var output = new(Output)
+var settings *model.Settings
+var watcher *model.Watcher
 
 //nolint:funlen
-func StartUI() error {
+func StartUI(appSettings *model.Settings) error {
 	options.DetailedWvw = true
+	options.MaxRetries = 3
+	options.DeselectWipes = true
 	output.FormatOptions.Title = "Training"
 	output.FormatOptions.IncludeDuration = true
-	output.Results.Discord = ""
-	output.Results.Teamspeak = ""
+	settings = appSettings
+	options.WatchFolder = settings.Watch
 
 	var mainWindow *walk.MainWindow
 	var tv *walk.TableView
@@ -69,13 +89,62 @@ func StartUI() error {
 	var db *walk.DataBinder
 	var versionLinkLabel *walk.LinkLabel
 	var outputFormatTabs *walk.TabWidget
+	var pauseButton *walk.PushButton
+	var postButton *walk.PushButton
+
+	outputTemplates := model.LoadOutputTemplates()
+	outputEdits := make([]*walk.TextEdit, len(outputTemplates))
+	outputPages := make([]declarative.TabPage, len(outputTemplates), len(outputTemplates)+1)
+	for i, outputTemplate := range outputTemplates {
+		outputPages[i] = declarative.TabPage{
+			Layout: declarative.VBox{},
+			Title:  outputTemplate.Name,
+			Children: []declarative.Widget{
+				declarative.TextEdit{
+					AssignTo:      &outputEdits[i],
+					StretchFactor: 10,
+					ReadOnly:      true,
+					HScroll:       true,
+					VScroll:       true,
+				},
+			},
+		}
+	}
+	var embedEdit *walk.TextEdit
+	outputPages = append(outputPages, declarative.TabPage{
+		Layout: declarative.VBox{},
+		Title:  embedsPageTitle,
+		Children: []declarative.Widget{
+			declarative.TextEdit{
+				AssignTo:      &embedEdit,
+				StretchFactor: 10,
+				ReadOnly:      true,
+				HScroll:       true,
+				VScroll:       true,
+			},
+		},
+	})
 
 	idler := utils.NewIdler(time.Duration(100)*time.Millisecond, func() {
-		res := generateMessageText(tableModel.items, output.FormatOptions)
-		output.Results = res
-		_ = db.Reset()
+		res := generateMessageText(tableModel.items, output.FormatOptions, outputTemplates, settings.TimeFormat)
+		embeds := generateDiscordEmbeds(tableModel.items, output.FormatOptions, settings.TimeFormat)
+		embedsJSON := formatEmbeds(embeds)
+		mainWindow.Synchronize(func() {
+			output.Results = res
+			output.Embeds = embeds
+			for i, result := range res {
+				_ = outputEdits[i].SetText(result.Text())
+			}
+			_ = embedEdit.SetText(embedsJSON)
+		})
 	})
 
+	model.UploadQueue.OnChange = func() {
+		if rows := len(tableModel.items); rows > 0 {
+			tableModel.PublishRowsChanged(0, rows-1)
+		}
+	}
+
 	changeCallback = func(arcLog *model.ArcLog, linkChanged bool) {
 		tableModel.PublishRowChanged(tableModel.IndexOf(arcLog))
 		updateProgress(tableModel, prog)
@@ -90,10 +159,24 @@ func StartUI() error {
 	isRetryAllowed := walk.NewMutableCondition()
 	declarative.MustRegisterCondition("isRetryAllowed", isRetryAllowed)
 
+	isReuploadAllowed := walk.NewMutableCondition()
+	declarative.MustRegisterCondition("isReuploadAllowed", isReuploadAllowed)
+
+	isUploadAnywayAllowed := walk.NewMutableCondition()
+	declarative.MustRegisterCondition("isUploadAnywayAllowed", isUploadAnywayAllowed)
+
+	isCancelAllowed := walk.NewMutableCondition()
+	declarative.MustRegisterCondition("isCancelAllowed", isCancelAllowed)
+
 	tableModel = new(ArcLogModel)
 
 	reprocessOutput = idler.Call
 
+	onWatchedFiles = func(files []string) {
+		mainWindow.Synchronize(func() {
+			onDrop(files, tableModel, prog)
+		})
+	}
 	go checkForUpdate(&versionLinkLabel)
 
 	var window = declarative.MainWindow{
@@ -135,12 +218,13 @@ func StartUI() error {
 											DataSource:     options,
 											ErrorPresenter: declarative.ToolTipErrorPresenter{},
 											AutoSubmit:     true,
+											OnSubmitted:    applyOptions,
 										},
 										Children: []declarative.Widget{
 											declarative.CheckBox{
 												Name:        "DetailedLogs",
 												Text:        "Use Detailed WvW Logs if possible.",
-												ToolTipText: "Detailed WvW is currently not possible for large log files. They will fallback to non-detailed upload.",
+												ToolTipText: "Only WvW logs below the size limit in the settings are uploaded as detailed, all others as non-detailed.",
 												Checked:     declarative.Bind("DetailedWvw"),
 											},
 											declarative.CheckBox{
@@ -149,6 +233,85 @@ func StartUI() error {
 												ToolTipText: "Replace player names in report.",
 												Checked:     declarative.Bind("Anonymous"),
 											},
+											declarative.CheckBox{
+												Name:        "FetchEliteInsights",
+												Text:        "Fetch Elite Insights data",
+												ToolTipText: "Download the detailed fight data of each report, e.g. for squad dps and remaining boss health.",
+												Checked:     declarative.Bind("FetchEliteInsights"),
+											},
+											declarative.CheckBox{
+												Name:        "DeselectWipes",
+												Text:        "Deselect wipes",
+												ToolTipText: "Do not select wipes and trash pulls for the output once they are uploaded.",
+												Checked:     declarative.Bind("DeselectWipes"),
+											},
+											declarative.CheckBox{
+												Name:        "WatchFolder",
+												Text:        "Watch log folder",
+												ToolTipText: "Automatically upload new logs written to the log folder configured in the settings.",
+												Checked:     declarative.Bind("WatchFolder"),
+											},
+											declarative.Label{
+												Text:        "Order",
+												ToolTipText: "Order in which queued logs are uploaded.",
+											},
+											declarative.ComboBox{
+												Value:         declarative.Bind("QueueOrder"),
+												BindingMember: "Order",
+												DisplayMember: "Name",
+												Model:         queueOrders,
+												ToolTipText:   "Order in which queued logs are uploaded.",
+											},
+											declarative.Label{
+												Text:        "Retries",
+												ToolTipText: "How often a failed upload is retried on network or server errors.",
+											},
+											declarative.NumberEdit{
+												Value:       declarative.Bind("MaxRetries"),
+												ToolTipText: "How often a failed upload is retried on network or server errors.",
+												MinValue:    0,
+												MaxValue:    10,
+												MaxSize:     declarative.Size{Width: 40},
+											},
+										},
+									},
+									declarative.Composite{
+										Layout: declarative.VBox{MarginsZero: true},
+										Children: []declarative.Widget{
+											declarative.PushButton{
+												Text: "Settings…",
+												OnClicked: func() {
+													if result, _ := runSettingsDialog(mainWindow); result == walk.DlgCmdOK {
+														applyWatcher()
+														tableModel.RefreshTable()
+														reprocessOutput()
+													}
+												},
+											},
+											declarative.PushButton{
+												AssignTo:    &pauseButton,
+												Text:        "Pause uploads",
+												ToolTipText: "Hold queued logs. Uploads already running are finished.",
+												OnClicked: func() {
+													togglePause(pauseButton)
+												},
+											},
+											declarative.PushButton{
+												Text:        "Import…",
+												ToolTipText: "Queue the logs of a time range from the log folder.",
+												OnClicked: func() {
+													_, _ = runImportDialog(mainWindow, func(files []string) {
+														onDrop(files, tableModel, prog)
+													})
+												},
+											},
+											declarative.PushButton{
+												Text:        "Import my uploads",
+												ToolTipText: "Add all reports uploaded with your dps.report user token to the list.",
+												OnClicked: func() {
+													importUploads(mainWindow, tableModel, prog)
+												},
+											},
 										},
 									},
 								},
@@ -176,13 +339,73 @@ func StartUI() error {
 													selectedIndexes := tv.SelectedIndexes()
 													for _, index := range selectedIndexes {
 														arcLog := tableModel.items[index]
-														if arcLog.Status == model.Error {
+														if arcLog.Status == model.Error || arcLog.Status == model.Cancelled {
 															log.Debugf("Reqeue requested: %v", arcLog)
-															go queueUpload(arcLog)
+															queueUpload(arcLog, false, false)
+														}
+													}
+												},
+											},
+											declarative.Action{
+												Text:    "Force re-upload",
+												Enabled: declarative.Bind("isReuploadAllowed"),
+												OnTriggered: func() {
+													selectedIndexes := tv.SelectedIndexes()
+													for _, index := range selectedIndexes {
+														arcLog := tableModel.items[index]
+														if arcLog.Status == model.Done && arcLog.File != "" {
+															log.Debugf("Re-upload requested: %v", arcLog)
+															queueUpload(arcLog, true, true)
+														}
+													}
+												},
+											},
+											declarative.Action{
+												Text:    "Upload anyway",
+												Enabled: declarative.Bind("isUploadAnywayAllowed"),
+												OnTriggered: func() {
+													selectedIndexes := tv.SelectedIndexes()
+													for _, index := range selectedIndexes {
+														arcLog := tableModel.items[index]
+														if arcLog.Status == model.Skipped {
+															log.Debugf("Upload of skipped log requested: %v", arcLog)
+															arcLog.SkipReason = ""
+															queueUpload(arcLog, false, true)
+														}
+													}
+												},
+											},
+											declarative.Action{
+												Text:    "Cancel upload",
+												Enabled: declarative.Bind("isCancelAllowed"),
+												OnTriggered: func() {
+													selectedIndexes := tv.SelectedIndexes()
+													for _, index := range selectedIndexes {
+														arcLog := tableModel.items[index]
+														if isCancelable(arcLog.Status) {
+															log.Debugf("Cancel requested: %v", arcLog)
+															arcLog.CancelUpload()
+															model.UploadQueue.Remove(arcLog)
+															arcLog.Status = model.Cancelled
+															arcLog.ErrorMessage = nil
+															changeCallback(arcLog, true)
 														}
 													}
 												},
 											},
+											declarative.Menu{
+												Text: "Columns",
+												Items: []declarative.MenuItem{
+													columnToggleAction(&tv, "Boss", true),
+													columnToggleAction(&tv, "Result", false),
+													columnToggleAction(&tv, "CM", false),
+													columnToggleAction(&tv, "Players", false),
+													columnToggleAction(&tv, "Recorded By", false),
+													columnToggleAction(&tv, "Squad DPS", false),
+													columnToggleAction(&tv, "Boss HP", false),
+													columnToggleAction(&tv, "Host", false),
+												},
+											},
 											declarative.Action{
 												Text:    "Open Log in Browser",
 												Enabled: declarative.Bind("tv.SelectedCount == 1 && isBrowseAllowed"),
@@ -205,8 +428,16 @@ func StartUI() error {
 										Columns: []declarative.TableViewColumn{
 											{Title: "File", Width: 150},
 											{Title: "Status", Width: 85},
-											{Title: "Date", Format: "2006-01-02 15:04:05", Width: 120},
+											{Title: "Date", Width: 120},
 											{Title: "Duration", Width: 60},
+											{Title: "Boss", Width: 120},
+											{Title: "Result", Width: 50, Hidden: true},
+											{Title: "CM", Width: 30, Hidden: true},
+											{Title: "Players", Width: 50, Hidden: true},
+											{Title: "Recorded By", Width: 100, Hidden: true},
+											{Title: "Squad DPS", Width: 70, Hidden: true},
+											{Title: "Boss HP", Width: 55, Hidden: true},
+											{Title: "Host", Width: 100, Hidden: true},
 											{Title: "Detailed", Width: 50},
 											{Title: "Anonymized", Width: 70},
 											{Title: "Link", Width: 260},
@@ -223,10 +454,19 @@ func StartUI() error {
 											}
 										},
 										Model: tableModel,
+										OnMouseMove: func(x, y int, button walk.MouseButton) {
+											text := cellToolTip(tv, tableModel, x, y)
+											if text != tv.ToolTipText() {
+												_ = tv.SetToolTipText(text)
+											}
+										},
 										OnSelectedIndexesChanged: func() {
 											fmt.Printf("SelectedIndexes: %v\n", tv.SelectedIndexes())
 											_ = isBrowsableAllowed.SetSatisfied(checkBrowsable(tv, tableModel))
 											_ = isRetryAllowed.SetSatisfied(shouldRetryBeAllowed(tv, tableModel))
+											_ = isReuploadAllowed.SetSatisfied(shouldReuploadBeAllowed(tv, tableModel))
+											_ = isUploadAnywayAllowed.SetSatisfied(shouldUploadAnywayBeAllowed(tv, tableModel))
+											_ = isCancelAllowed.SetSatisfied(shouldCancelBeAllowed(tv, tableModel))
 										},
 									},
 								},
@@ -271,6 +511,14 @@ func StartUI() error {
 												Checked:     declarative.Bind("FormatOptions.IncludeDuration"),
 												ToolTipText: "Show combat time for each log",
 											},
+											declarative.Label{
+												Text:        "Discord Timestamps",
+												ToolTipText: "Show dates and times on Discord in the time zone of each reader",
+											},
+											declarative.CheckBox{
+												Checked:     declarative.Bind("FormatOptions.DiscordTimestamps"),
+												ToolTipText: "Show dates and times on Discord in the time zone of each reader",
+											},
 										},
 									},
 								},
@@ -283,34 +531,7 @@ func StartUI() error {
 									declarative.TabWidget{
 										StretchFactor: 10,
 										AssignTo:      &outputFormatTabs,
-										Pages: []declarative.TabPage{
-											{
-												Layout: declarative.VBox{},
-												Title:  "Discord",
-												Children: []declarative.Widget{
-													declarative.TextEdit{
-														StretchFactor: 10,
-														Text:          declarative.Bind("Results.Discord"),
-														ReadOnly:      true,
-														HScroll:       true,
-														VScroll:       true,
-													},
-												},
-											},
-											{
-												Layout: declarative.VBox{},
-												Title:  "Teamspeak",
-												Children: []declarative.Widget{
-													declarative.TextEdit{
-														StretchFactor: 10,
-														Text:          declarative.Bind("Results.Teamspeak"),
-														ReadOnly:      true,
-														HScroll:       true,
-														VScroll:       true,
-													},
-												},
-											},
-										},
+										Pages:         outputPages,
 									},
 									declarative.Composite{
 										Layout: declarative.HBox{MarginsZero: true},
@@ -320,6 +541,16 @@ func StartUI() error {
 											declarative.ProgressBar{
 												AssignTo: &prog,
 											},
+											declarative.PushButton{
+												AssignTo: &postButton,
+												Text:     "Post to Discord",
+												OnClicked: func() {
+													index := outputFormatTabs.CurrentIndex()
+													embeds := outputFormatTabs.Pages().At(index).Title() == embedsPageTitle
+													postToDiscord(mainWindow, postButton, embeds)
+												},
+												MinSize: declarative.Size{Width: 100},
+											},
 											declarative.PushButton{
 												AssignTo: &button,
 												Text:     "Copy to Clipboard",
@@ -403,9 +634,41 @@ func StartUI() error {
 			},
 		},
 	}
-	var err error
-	_, err = window.Run()
-	return err
+	var err = window.Create()
+	if err != nil {
+		return err
+	}
+
+	if settings.UserToken == "" {
+		go ensureUserToken(mainWindow)
+	}
+	applyWatcher()
+	defer stopWatcher()
+
+	mainWindow.Run()
+	return nil
+}
+
+// columnToggleAction creates a checkable action which shows or hides the table column with the given title.
+func columnToggleAction(tv **walk.TableView, title string, visible bool) declarative.Action {
+	var action *walk.Action
+	return declarative.Action{
+		AssignTo:  &action,
+		Text:      title,
+		Checkable: true,
+		Checked:   visible,
+		OnTriggered: func() {
+			columns := (*tv).Columns()
+			for i := 0; i < columns.Len(); i++ {
+				column := columns.At(i)
+				if column.Title() == title {
+					visible := !column.Visible()
+					_ = column.SetVisible(visible)
+					_ = action.SetChecked(visible)
+				}
+			}
+		},
+	}
 }
 
 func checkForUpdate(versionLinkLabel **walk.LinkLabel) {
@@ -438,13 +701,70 @@ func shouldRetryBeAllowed(tv *walk.TableView, m *ArcLogModel) bool {
 	}
 	indexes := tv.SelectedIndexes()
 	for _, index := range indexes {
-		if m.items[index].Status == model.Error {
+		if m.items[index].Status == model.Error || m.items[index].Status == model.Cancelled {
 			return true
 		}
 	}
 	return false
 }
 
+func shouldReuploadBeAllowed(tv *walk.TableView, m *ArcLogModel) bool {
+	for _, index := range tv.SelectedIndexes() {
+		if m.items[index].Status == model.Done && m.items[index].File != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func shouldUploadAnywayBeAllowed(tv *walk.TableView, m *ArcLogModel) bool {
+	for _, index := range tv.SelectedIndexes() {
+		if m.items[index].Status == model.Skipped {
+			return true
+		}
+	}
+	return false
+}
+
+func shouldCancelBeAllowed(tv *walk.TableView, m *ArcLogModel) bool {
+	for _, index := range tv.SelectedIndexes() {
+		if isCancelable(m.items[index].Status) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCancelable(status model.LogStatus) bool {
+	switch status {
+	case model.WaitingInQueue, model.Paused, model.WaitingRateLimiting, model.WaitingRateLimitingHard,
+		model.Uploading, model.WaitingRetry:
+		return true
+	case model.Outstanding, model.Done, model.Error, model.Cancelled, model.Skipped:
+		return false
+	}
+	return false
+}
+
+// cellToolTip returns the tool tip for the cell at the given position, explaining why detailed WvW was forced off.
+func cellToolTip(tv *walk.TableView, m *ArcLogModel, x, y int) string {
+	index := tv.IndexAt(x, y)
+	if index < 0 || index >= len(m.items) || m.items[index].DetailedReason == "" {
+		return ""
+	}
+	right := 0
+	for _, column := range tv.VisibleColumnsInDisplayOrder() {
+		right += column.Width()
+		if x < right {
+			if column.Title() == "Detailed" {
+				return "Detailed WvW forced off: " + m.items[index].DetailedReason
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
 func checkBrowsable(tv *walk.TableView, m *ArcLogModel) bool {
 	if len(tv.SelectedIndexes()) == 1 {
 		arcLog := m.items[tv.SelectedIndexes()[0]]
@@ -470,8 +790,8 @@ func onDrop(files []string, m *ArcLogModel, prog *walk.ProgressBar) {
 			// handle if item already exists in list
 			possibleIndex, existingItem := fileAlreadyInList(m, file)
 			if possibleIndex >= 0 {
-				if existingItem.Report == nil {
-					go queueUpload(existingItem)
+				if existingItem.Report == nil && !isCancelable(existingItem.Status) && existingItem.Status != model.Skipped {
+					queueUpload(existingItem, false, false)
 				}
 				continue
 			}
@@ -483,8 +803,20 @@ func onDrop(files []string, m *ArcLogModel, prog *walk.ProgressBar) {
 			m.items = append(m.items, newElem)
 			var index = len(m.items) - 1
 			m.PublishRowsInserted(index, index)
-
-			go queueUpload(newElem)
+			model.ReadLogAsync(newElem, func() {
+				changeCallback(newElem, false)
+			}, func() {
+				changeCallback(newElem, false)
+			})
+
+			if reason := settings.SkipReason(file); reason != "" {
+				log.Debugf("Skipping %v: %v", filename, reason)
+				newElem.Status = model.Skipped
+				newElem.SkipReason = reason
+				changeCallback(newElem, false)
+				continue
+			}
+			queueUpload(newElem, false, false)
 		} else {
 			log.Debugf("%v does not match the arc log file patern", filename)
 		}
@@ -492,6 +824,101 @@ func onDrop(files []string, m *ArcLogModel, prog *walk.ProgressBar) {
 	updateProgress(m, prog)
 }
 
+var onWatchedFiles func(files []string)
+
+// applyOptions makes changed upload options effective once the data binder submitted them.
+func applyOptions() {
+	if model.UploadQueue.Order() != options.QueueOrder {
+		model.UploadQueue.SetOrder(options.QueueOrder)
+	}
+	if settings.Watch != options.WatchFolder {
+		settings.Watch = options.WatchFolder
+		if err := settings.Save(); err != nil {
+			log.Errorf("Could not save settings: %v", err)
+		}
+		applyWatcher()
+	}
+}
+
+// applyWatcher (re)starts or stops watching the log folder according to the current options and settings.
+func applyWatcher() {
+	stopWatcher()
+	if !options.WatchFolder || settings.WatchFolder == "" {
+		return
+	}
+	watcher = model.NewWatcher(settings.WatchFolder, func(path string) bool {
+		return logFilePattern.MatchString(strings.ToLower(filepath.Base(path)))
+	}, onWatchedFiles)
+	watcher.Start()
+}
+
+func stopWatcher() {
+	if watcher != nil {
+		watcher.Stop()
+		watcher = nil
+	}
+}
+
+func togglePause(pauseButton *walk.PushButton) {
+	if model.UploadQueue.Paused() {
+		model.UploadQueue.Resume()
+		_ = pauseButton.SetText("Pause uploads")
+	} else {
+		model.UploadQueue.Pause()
+		_ = pauseButton.SetText("Resume uploads")
+	}
+}
+
+func importUploads(mainWindow *walk.MainWindow, m *ArcLogModel, prog *walk.ProgressBar) {
+	userToken := settings.UserToken
+	if userToken == "" {
+		walk.MsgBox(mainWindow, "Import my uploads", "No dps.report user token is configured yet. Please set one in the settings.",
+			walk.MsgBoxOK|walk.MsgBoxIconInformation)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		err := model.API.GetAllUploads(ctx, userToken, func(uploads []model.DpsReportResponse) {
+			mainWindow.Synchronize(func() {
+				addImportedReports(uploads, m, prog)
+			})
+		})
+		if err != nil {
+			log.Errorf("Could not import uploads: %v", err)
+			mainWindow.Synchronize(func() {
+				walk.MsgBox(mainWindow, "Import my uploads", "Could not import uploads:\n"+err.Error(),
+					walk.MsgBoxOK|walk.MsgBoxIconError)
+			})
+		}
+	}()
+}
+
+func addImportedReports(reports []model.DpsReportResponse, m *ArcLogModel, prog *walk.ProgressBar) {
+	for i := range reports {
+		report := reports[i]
+		if report.Permalink == "" || reportAlreadyInList(m, report.Permalink) {
+			continue
+		}
+
+		newElem := new(model.ArcLog)
+		newElem.Status = model.Done
+		newElem.SetReport(&report)
+		newElem.Anonymized = report.Report.Anonymous
+		if report.Report.Detailed {
+			newElem.Detailed = model.True
+		} else {
+			newElem.Detailed = model.False
+		}
+		m.items = append(m.items, newElem)
+		var index = len(m.items) - 1
+		m.PublishRowsInserted(index, index)
+	}
+	updateProgress(m, prog)
+}
+
 func onFolderDrop(file string) ([]string, error) {
 	var folderFiles []string
 	err := filepath.Walk(file, func(path string, info os.FileInfo, err error) error {
@@ -503,9 +930,15 @@ func onFolderDrop(file string) ([]string, error) {
 	return folderFiles, err
 }
 
-func queueUpload(newElem *model.ArcLog) {
+// queueUpload adds the log to the upload queue. Force uploads it again even if it was uploaded before,
+// anyway uploads it even if it is too short.
+func queueUpload(newElem *model.ArcLog, force, anyway bool) {
 	uploadOptions := getCurrentOptions()
+	if anyway {
+		uploadOptions.MinDuration = 0
+	}
 	newElem.Anonymized = uploadOptions.Anonymous
+	newElem.DetailedReason = ""
 	if uploadOptions.DetailedWvw {
 		newElem.Detailed = model.True
 	} else {
@@ -513,20 +946,34 @@ func queueUpload(newElem *model.ArcLog) {
 	}
 
 	onDone := func(report *model.DpsReportResponse, err error) {
-		if err != nil {
+		var skipped *model.SkippedError
+		if errors.Is(err, context.Canceled) {
+			newElem.Status = model.Cancelled
+			newElem.ErrorMessage = nil
+		} else if errors.As(err, &skipped) {
+			newElem.Status = model.Skipped
+			newElem.SkipReason = skipped.Reason
+			newElem.ErrorMessage = nil
+		} else if err != nil {
 			newElem.Status = model.Error
 			newElem.ErrorMessage = err
 		} else {
 			newElem.Status = model.Done
-			newElem.Report = report
-			newElem.Checked = true
+			newElem.SetReport(report)
+			newElem.Checked = !options.DeselectWipes || newElem.Result != model.ResultWipe
+			// short logs that could not be skipped before, as their duration was unknown, are at least not selected
+			if duration, found := newElem.CombatDuration(); found && model.TooShort(duration, settings.MinDuration()) != "" {
+				newElem.Checked = false
+			}
 		}
 		changeCallback(newElem, true)
 	}
 
 	entry := model.QueueEntry{
+		Context: newElem.NewUploadContext(),
 		ArcLog:  newElem,
 		Options: &uploadOptions,
+		Force:   force,
 		OnDone:  onDone,
 		OnChange: func() {
 			changeCallback(newElem, false)
@@ -537,13 +984,22 @@ func queueUpload(newElem *model.ArcLog) {
 	changeCallback(newElem, false)
 
 	// queue entry
-	model.UploadQueue <- entry
+	model.UploadQueue.Push(entry)
 }
 
 func getCurrentOptions() model.UploadOptions {
 	uploadOptions := model.UploadOptions{
 		DetailedWvw: options.DetailedWvw,
 		Anonymous:   options.Anonymous,
+		MaxRetries:  options.MaxRetries,
+		UserToken:   settings.UserToken,
+		Hosts:       settings.Hosts(),
+
+		FetchEliteInsights: options.FetchEliteInsights,
+		MaxDetailedSize:    settings.MaxDetailedWvwSize(),
+		MinDuration:        settings.MinDuration(),
+		ConvertEvtc:        settings.ConvertEvtc,
+		KeepOriginalEvtc:   settings.KeepOriginalEvtc,
 	}
 	return uploadOptions
 }
@@ -556,7 +1012,7 @@ func updateProgress(m *ArcLogModel, progressBar *walk.ProgressBar) {
 
 	var count = 0
 	for _, v := range m.items {
-		if v.Status == model.Done || v.Status == model.Error {
+		if v.Status == model.Done || v.Status == model.Error || v.Status == model.Cancelled || v.Status == model.Skipped {
 			// Append desired values to slice
 			count++
 		}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package utils
+
+import (
+	"strings"
+)
+
+// IsHidden reports whether the file is hidden, which is marked by a leading dot.
+func IsHidden(_, name string) bool {
+	return strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package utils
+
+import (
+	"strings"
+	"syscall"
+)
+
+// IsHidden reports whether the file is hidden, either by a leading dot or the windows hidden attribute.
+func IsHidden(path, name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	pointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attributes, err := syscall.GetFileAttributes(pointer)
+	if err != nil {
+		return false
+	}
+	return attributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
@@ -14,6 +14,9 @@ func (t JSONTime) MarshalJSON() ([]byte, error) {
 
 func (t *JSONTime) UnmarshalJSON(s []byte) (err error) {
 	r := strings.ReplaceAll(string(s), `"`, ``)
+	if r == "null" || r == "" {
+		return nil
+	}
 
 	q, err := strconv.ParseInt(r, 10, 64)
 	if err != nil {
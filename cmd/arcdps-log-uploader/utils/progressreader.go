@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"io"
+)
+
+// ProgressReader counts the bytes read from the underlying reader and reports the progress in percent.
+type ProgressReader struct {
+	reader      io.Reader
+	total       int64
+	read        int64
+	lastPercent int
+	onProgress  func(percent int)
+}
+
+// Read reads from the underlying reader and publishes the progress whenever the percentage changes.
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if r.total > 0 {
+		percent := int(r.read * 100 / r.total)
+		if percent > 100 {
+			percent = 100
+		}
+		if percent != r.lastPercent {
+			r.lastPercent = percent
+			r.onProgress(percent)
+		}
+	}
+	return n, err
+}
+
+// NewProgressReader returns a reader that reports the progress of reading total bytes from reader.
+func NewProgressReader(reader io.Reader, total int64, onProgress func(percent int)) *ProgressReader {
+	return &ProgressReader{
+		reader:     reader,
+		total:      total,
+		onProgress: onProgress,
+	}
+}
@@ -1,24 +1,23 @@
 package utils
 
 import (
-	"context"
 	"net/http"
-
-	"golang.org/x/time/rate"
 )
 
 // RLHTTPClient Rate Limited HTTP Client.
 type RLHTTPClient struct {
 	client      *http.Client
-	RateLimiter *rate.Limiter
+	RateLimiter *RateLimiter
 }
 
 // Do dispatches the HTTP request to the network.
 func (c *RLHTTPClient) Do(req *http.Request, activationCallback func()) (*http.Response, error) {
 	// Comment out the below 5 lines to turn off ratelimiting
-	ctx := context.Background()
-	err := c.RateLimiter.Wait(ctx) // This is a blocking call. Honors the rate limit
+	err := c.RateLimiter.Wait(req.Context()) // This is a blocking call. Honors the rate limit
 	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
 		return nil, err
 	}
 	activationCallback()
@@ -26,13 +25,14 @@ func (c *RLHTTPClient) Do(req *http.Request, activationCallback func()) (*http.R
 	if err != nil {
 		return nil, err
 	}
+	c.RateLimiter.Update(resp)
 	return resp, nil
 }
 
 // NewRateLimitedClient return http client with a rateLimiter.
-func NewRateLimitedClient(rl *rate.Limiter) *RLHTTPClient {
+func NewRateLimitedClient(client *http.Client, rl *RateLimiter) *RLHTTPClient {
 	c := &RLHTTPClient{
-		client:      http.DefaultClient,
+		client:      client,
 		RateLimiter: rl,
 	}
 	return c
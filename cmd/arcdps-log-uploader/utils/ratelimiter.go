@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// unbanMargin is waited on top of the time the server asked for, to not hit the limit again due to clock differences.
+const unbanMargin = 2 * time.Second
+
+type rateLimiterState struct {
+	BlockedUntil time.Time `json:"blockedUntil"`
+}
+
+// RateLimiter is shared by all requests to a server. It combines a token bucket with the rate limit headers
+// sent by the server and blocks all requests together after the server rejected one with 429 Too Many Requests.
+type RateLimiter struct {
+	mu           sync.Mutex
+	limiter      *rate.Limiter
+	blockedUntil time.Time
+	stateFile    string
+}
+
+// NewRateLimiter returns a rate limiter based on the given token bucket.
+// If stateFile is not empty, blocks are persisted in the config file of that name, so they survive restarts.
+func NewRateLimiter(limiter *rate.Limiter, stateFile string) *RateLimiter {
+	l := &RateLimiter{limiter: limiter, stateFile: stateFile}
+	if stateFile != "" {
+		state := rateLimiterState{}
+		if err := LoadJSON(stateFile, &state); err != nil {
+			log.Errorf("Could not load rate limit state: %v", err)
+		}
+		l.blockedUntil = state.BlockedUntil
+	}
+	return l
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		blockedFor := time.Until(l.BlockedUntil())
+		if blockedFor <= 0 {
+			break
+		}
+		log.Debugf("Waiting to be unblocked (in %v)", blockedFor)
+		timer := time.NewTimer(blockedFor)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return l.limiter.Wait(ctx)
+}
+
+// BlockedUntil returns the time until which the server refuses requests.
+func (l *RateLimiter) BlockedUntil() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.blockedUntil
+}
+
+// Blocked reports whether the server currently refuses requests.
+func (l *RateLimiter) Blocked() bool {
+	return l.BlockedUntil().After(time.Now())
+}
+
+// Update adjusts the limiter to the rate limit headers of the response.
+func (l *RateLimiter) Update(res *http.Response) {
+	now := time.Now()
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		retryAfter, ok := ParseRetryAfter(res.Header.Get("Retry-After"), now)
+		if !ok {
+			retryAfter = resetIn(res.Header, now)
+		}
+		l.block(now.Add(retryAfter + unbanMargin))
+		return
+	}
+
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	if remaining <= 0 {
+		if reset := resetIn(res.Header, now); reset > 0 {
+			l.block(now.Add(reset + unbanMargin))
+		}
+		return
+	}
+
+	// never hand out more tokens than the server has left for us
+	if surplus := int(l.limiter.TokensAt(now)) - remaining; surplus > 0 {
+		l.limiter.AllowN(now, surplus)
+	}
+}
+
+func (l *RateLimiter) block(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !until.After(l.blockedUntil) {
+		return
+	}
+	l.blockedUntil = until
+	log.Warnf("Rate limited by server until %v", until.Format(time.TimeOnly))
+
+	if l.stateFile != "" {
+		if err := SaveJSON(l.stateFile, rateLimiterState{BlockedUntil: until}); err != nil {
+			log.Errorf("Could not save rate limit state: %v", err)
+		}
+	}
+}
+
+// resetIn returns how long it takes until the rate limit window resets, according to the X-RateLimit-Reset header.
+// The header is interpreted as unix timestamp if it is large enough to be one, otherwise as seconds.
+func resetIn(header http.Header, now time.Time) time.Duration {
+	reset, err := strconv.ParseInt(strings.TrimSpace(header.Get("X-RateLimit-Reset")), 10, 64)
+	if err != nil || reset <= 0 {
+		return 0
+	}
+	const unixTimestampThreshold = 1_000_000_000
+	if reset > unixTimestampThreshold {
+		return time.Unix(reset, 0).Sub(now)
+	}
+	return time.Duration(reset) * time.Second
+}
+
+// ParseRetryAfter parses a Retry-After header value, which is either a number of seconds or an HTTP date.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if date.Before(now) {
+			return 0, true
+		}
+		return date.Sub(now), true
+	}
+	return 0, false
+}